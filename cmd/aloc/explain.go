@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/modern-tooling/aloc/internal/inference"
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/progress"
+	"github.com/modern-tooling/aloc/internal/renderer"
+	"github.com/modern-tooling/aloc/internal/renderer/tui"
+	"github.com/modern-tooling/aloc/internal/scanner"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var explainRootFlag string
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <path>...",
+	Short: "Show why files are classified the way they are",
+	Long: `explain classifies the project like a normal run and prints, for each
+given file (or every file under a given directory), the rules that fired
+with their weights, the stages that were skipped, how the weights resolved,
+and the neighborhood vote of the file's directory.
+
+Use it to tune overrides and rules in aloc.yaml.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+	explainCmd.Flags().StringVar(&explainRootFlag, "root", ".", "Project root the paths belong to (where aloc.yaml lives)")
+	explainCmd.Flags().StringVarP(&formatFlag, "format", "f", "tui", "Output format (tui, json)")
+	explainCmd.Flags().BoolVar(&noColorFlag, "no-color", false, "Disable colors")
+	explainCmd.Flags().BoolVar(&prettyFlag, "pretty", false, "Pretty-print JSON output")
+	explainCmd.Flags().BoolVar(&headerProbeFlag, "header-probe", false, "Enable header content probing")
+	explainCmd.Flags().BoolVar(&deepFlag, "deep", false, "Enable expensive analysis (header probing, extensionless files)")
+	explainCmd.Flags().StringVarP(&configFlag, "config", "c", "", "Config file path")
+	explainCmd.Flags().StringVar(&submodulesFlag, "submodules", "", "How to treat git submodules and nested checkouts: vendor, skip or recurse (default vendor)")
+	explainCmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Re-count every file instead of reusing cached results for unchanged files")
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	absRoot, err := filepath.Abs(explainRootFlag)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	proj, err := loadProject(absRoot)
+	if err != nil {
+		return err
+	}
+
+	// Files are identified by their path relative to the root, as in a report
+	targets := make([]string, len(args))
+	for i, arg := range args {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(absRoot, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is outside %s (set --root)", arg, absRoot)
+		}
+		targets[i] = rel
+	}
+	found := make([]bool, len(targets))
+	selected := func(path string) bool {
+		var ok bool
+		for i, t := range targets {
+			if t == "." || path == t || strings.HasPrefix(path, t+string(filepath.Separator)) {
+				found[i] = true
+				ok = true
+			}
+		}
+		return ok
+	}
+
+	// The whole project is scanned: neighborhood votes need every file of a
+	// directory, and excludes and submodules apply as in a normal run
+	scanOpts := proj.scanOptions()
+	scanOpts.Cache = openCache(absRoot)
+	s, err := scanner.NewScanner(absRoot, scanOpts)
+	if err != nil {
+		return fmt.Errorf("scanner error: %w", err)
+	}
+
+	var prog *progress.Reporter
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		prog = progress.Start(os.Stderr)
+	}
+	defer prog.Stop()
+
+	rawFiles, errs := s.Scan(ctx)
+	var files []*model.RawFile
+	for f := range rawFiles {
+		files = append(files, f)
+		prog.File(f.Bytes)
+	}
+
+	// Only problems with the files being explained are worth a warning
+	var warnings []error
+	for err := range errs {
+		var decodeErr *scanner.DecodeError
+		if errors.As(err, &decodeErr) && selected(decodeErr.Path) {
+			warnings = append(warnings, err)
+		}
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted")
+	}
+	if scanOpts.Cache != nil {
+		if err := scanOpts.Cache.Save(); err != nil {
+			warnings = append(warnings, fmt.Errorf("scan cache: %w", err))
+		}
+	}
+
+	prog.Phase("classifying")
+	explanations := proj.engine().Explain(files, selected)
+	prog.Stop()
+	logWarnings(warnings)
+
+	for i := range targets {
+		if !found[i] {
+			fmt.Fprintf(os.Stderr, "warning: %s: not counted (excluded, binary, or not a recognized source file; --deep also scans extensionless files)\n", args[i])
+		}
+	}
+	if len(explanations) == 0 {
+		return fmt.Errorf("no counted files match %s", strings.Join(args, " "))
+	}
+	slices.SortFunc(explanations, func(a, b *inference.Explanation) int { return strings.Compare(a.Path, b.Path) })
+
+	if formatFlag == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		if prettyFlag {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(explanations)
+	}
+	return renderExplanations(explanations)
+}
+
+func renderExplanations(explanations []*inference.Explanation) error {
+	theme := renderer.NewDefaultTheme()
+	if noColorFlag || renderer.ShouldDisableColor() {
+		theme = renderer.NewNoColorTheme()
+	}
+	for i, x := range explanations {
+		if i > 0 {
+			fmt.Println()
+		}
+		if _, err := fmt.Print(tui.RenderExplanation(x, theme)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
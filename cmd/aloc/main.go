@@ -2,22 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/modern-tooling/aloc/internal/aggregator"
+	"github.com/modern-tooling/aloc/internal/codeowners"
 	"github.com/modern-tooling/aloc/internal/effort"
 	"github.com/modern-tooling/aloc/internal/git"
 	"github.com/modern-tooling/aloc/internal/inference"
 	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/modules"
+	"github.com/modern-tooling/aloc/internal/progress"
 	"github.com/modern-tooling/aloc/internal/renderer"
 	jsonrenderer "github.com/modern-tooling/aloc/internal/renderer/json"
 	"github.com/modern-tooling/aloc/internal/renderer/tui"
 	"github.com/modern-tooling/aloc/internal/scanner"
 	"github.com/modern-tooling/aloc/pkg/config"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 // Build-time variables (set via ldflags)
@@ -48,6 +59,11 @@ var (
 	profileFlag        string
 	engineerFlag       bool
 	engineerMonthsFlag int
+	revFlag            string
+	noCacheFlag        bool
+	submodulesFlag     string
+	timeoutFlag        time.Duration
+	byFlag             string
 )
 
 var rootCmd = &cobra.Command{
@@ -90,7 +106,7 @@ func init() {
 	rootCmd.Flags().BoolVar(&noEffortFlag, "no-effort", false, "Disable effort estimates")
 	rootCmd.Flags().StringVar(&aiModelFlag, "ai-model", "sonnet", "AI model for cost estimation (sonnet, opus, haiku)")
 	rootCmd.Flags().Float64Var(&humanCostFlag, "human-cost", 0, "Monthly cost per engineer (0 = use blended cost from team composition)")
-	rootCmd.Flags().BoolVar(&noEmbeddedFlag, "no-embedded", false, "Hide embedded code blocks in Markdown")
+	rootCmd.Flags().BoolVar(&noEmbeddedFlag, "no-embedded", false, "Hide embedded code (Markdown code blocks, notebook cells, component scripts and styles)")
 	rootCmd.Flags().BoolVar(&gitFlag, "git", false, "Enable git history analysis for churn and stability signals")
 	rootCmd.Flags().IntVar(&gitMonthsFlag, "git-months", 6, "Months of history for sparklines")
 	rootCmd.Flags().BoolVar(&gitSmoothFlag, "git-smooth", false, "Use bi-weekly buckets instead of weekly for smoother sparklines")
@@ -98,6 +114,11 @@ func init() {
 	rootCmd.Flags().StringVar(&profileFlag, "profile", "faang", "Effort estimation profile (faang)")
 	rootCmd.Flags().BoolVar(&engineerFlag, "engineer", false, "Show engineer throughput analysis (replaces standard output)")
 	rootCmd.Flags().IntVar(&engineerMonthsFlag, "engineer-months", 6, "Months of history for engineer analysis")
+	rootCmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Re-count every file instead of reusing cached results for unchanged files")
+	rootCmd.Flags().StringVar(&submodulesFlag, "submodules", "", "How to treat git submodules and nested checkouts: vendor, skip or recurse (default vendor)")
+	rootCmd.Flags().StringVar(&revFlag, "rev", "", "Analyze a git commit, tag or branch instead of the working tree")
+	rootCmd.Flags().StringVar(&byFlag, "by", "role", "Primary grouping of the report (role, owner, module); owner needs a CODEOWNERS file, module build manifests")
+	rootCmd.Flags().DurationVar(&timeoutFlag, "timeout", 0, "Stop after this long (e.g. 10m) and report what was collected, marked partial (0 = no limit)")
 }
 
 func main() {
@@ -112,7 +133,20 @@ func main() {
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	// SIGINT/SIGTERM and --timeout stop the run; whatever was collected is
+	// still reported, marked partial
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if timeoutFlag > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeoutFlag)
+		defer cancel()
+	}
+	go func() {
+		// restore default handling so a second Ctrl-C kills aloc outright
+		<-ctx.Done()
+		stop()
+	}()
 
 	// Load model config early (before any effort calculations)
 	// Priority: --model-config file > --profile > default profile (faang)
@@ -131,6 +165,10 @@ func run(cmd *cobra.Command, args []string) error {
 		effort.SetModelConfig(modelCfg)
 	}
 
+	if byFlag != "role" && byFlag != "owner" && byFlag != "module" {
+		return fmt.Errorf("invalid --by %q (want role, owner or module)", byFlag)
+	}
+
 	// Determine root path
 	root := "."
 	if len(args) > 0 {
@@ -141,54 +179,116 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid path: %w", err)
 	}
 
-	// Load config
-	var cfg *config.Config
-	if configFlag != "" {
-		cfg, err = config.Load(configFlag)
-	} else {
-		cfg, err = config.LoadFromDir(absRoot)
-	}
+	proj, err := loadProject(absRoot)
 	if err != nil {
-		return fmt.Errorf("config error: %w", err)
+		return err
 	}
+	submodules := proj.submodules
 
-	// Create scanner
-	s, err := scanner.NewScanner(absRoot, scanner.Options{
-		NumWorkers: runtime.NumCPU() * 2,
-		Exclude:    cfg.Exclude,
-		DeepMode:   deepFlag,
-	})
-	if err != nil {
-		return fmt.Errorf("scanner error: %w", err)
+	// Create scanner (a git revision is read from the object store, not checked out)
+	scanOpts := proj.scanOptions()
+	scanOpts.Manifests = modules.Manifests
+	var scan func(context.Context) (<-chan *model.RawFile, <-chan error)
+	var manifests func() map[string][]byte
+	var revCommit string
+	if revFlag != "" {
+		revCommit, err = git.ResolveRev(absRoot, revFlag)
+		if err != nil {
+			return err
+		}
+		s, err := scanner.NewRevScanner(absRoot, revCommit, scanOpts)
+		if err != nil {
+			return fmt.Errorf("scanner error: %w", err)
+		}
+		scan, manifests = s.Scan, s.Manifests
+	} else {
+		scanOpts.Cache = openCache(absRoot)
+		s, err := scanner.NewScanner(absRoot, scanOpts)
+		if err != nil {
+			return fmt.Errorf("scanner error: %w", err)
+		}
+		scan, manifests = s.Scan, s.Manifests
 	}
 
+	// Progress goes to stderr, and only to a terminal
+	var prog *progress.Reporter
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		prog = progress.Start(os.Stderr)
+	}
+	defer prog.Stop()
+
 	// Scan files
-	rawFiles, errs := s.Scan(ctx)
+	rawFiles, errs := scan(ctx)
 
 	// Collect files
 	var files []*model.RawFile
 	for f := range rawFiles {
 		files = append(files, f)
+		prog.File(f.Bytes)
 	}
 
-	// Log errors (non-fatal)
+	// Errors are non-fatal; they are logged once the progress line is gone
+	var warnings []error
 	for err := range errs {
-		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		warnings = append(warnings, err)
+	}
+
+	if scanOpts.Cache != nil {
+		save := scanOpts.Cache.Save
+		if ctx.Err() != nil {
+			save = scanOpts.Cache.SavePartial
+		}
+		if err := save(); err != nil {
+			warnings = append(warnings, fmt.Errorf("scan cache: %w", err))
+		}
 	}
 
 	if len(files) == 0 {
+		prog.Stop()
+		logWarnings(warnings)
+		if reason := partialReason(ctx); reason != "" {
+			return fmt.Errorf("no files counted before the scan was stopped (%s)", reason)
+		}
+		if revFlag != "" {
+			return fmt.Errorf("no files found in %s at %s", absRoot, revFlag)
+		}
 		return fmt.Errorf("no files found in %s", absRoot)
 	}
 
-	// Create inference engine
-	engine := inference.NewEngine(inference.Options{
-		HeaderProbe:  deepFlag || headerProbeFlag || cfg.Options.HeaderProbe,
-		Neighborhood: cfg.Options.Neighborhood,
-		Overrides:    cfg.Overrides,
-	})
-
 	// Infer roles
-	records := engine.InferBatch(files)
+	prog.Phase("classifying")
+	records := proj.engine().InferBatch(files)
+
+	// Files get the team CODEOWNERS assigns them, read from the revision
+	// being analyzed
+	var owners *codeowners.CodeOwners
+	if revFlag != "" {
+		owners, err = codeowners.LoadRev(absRoot, revCommit)
+	} else {
+		owners, err = codeowners.Load(absRoot)
+	}
+	if err != nil {
+		warnings = append(warnings, fmt.Errorf("CODEOWNERS: %w", err))
+	}
+	if owners == nil && byFlag == "owner" {
+		prog.Stop()
+		logWarnings(warnings)
+		return fmt.Errorf("--by owner: no CODEOWNERS file found (looked for %s)", strings.Join(codeowners.Locations, ", "))
+	}
+	if owners != nil {
+		owners.Assign(records)
+	}
+
+	// Build manifests split the project into modules, each reported on its own
+	var projectModules []model.Module
+	if found := modules.Detect(manifests()); modules.Reported(found) {
+		projectModules = found
+		modules.Assign(projectModules, records)
+	} else if byFlag == "module" {
+		prog.Stop()
+		logWarnings(warnings)
+		return fmt.Errorf("--by module: the project is not split into modules (looked for %s)", strings.Join(modules.Manifests, ", "))
+	}
 
 	// Determine if effort should be included (default true, unless --no-effort)
 	includeEffort := effortFlag && !noEffortFlag
@@ -197,7 +297,10 @@ func run(cmd *cobra.Command, args []string) error {
 	enableGit := gitFlag || engineerFlag
 
 	// Aggregate
-	report := aggregator.Compute(records, aggregator.Options{
+	if enableGit {
+		prog.Phase("git history")
+	}
+	report := aggregator.Compute(ctx, records, aggregator.Options{
 		IncludeFiles:  filesFlag,
 		IncludeEffort: includeEffort,
 		EffortOpts: aggregator.EffortOptions{
@@ -207,27 +310,48 @@ func run(cmd *cobra.Command, args []string) error {
 			HumanCostPerMonth: humanCostFlag,
 		},
 		RepoInfo: &model.RepoInfo{
-			Name: filepath.Base(absRoot),
-			Root: absRoot,
+			Name:   filepath.Base(absRoot),
+			Commit: revCommit,
+			Root:   absRoot,
 		},
 		GitAnalysis: enableGit,
 		GitOpts: git.Options{
 			SparklineMonths: gitMonthsFlag,
 			StabilityMonths: 18,
 			Smooth:          gitSmoothFlag,
+			Rev:             revCommit,
+			Commits:         prog.CommitCounter(),
 		},
+		SubmoduleHistory: submodules == scanner.SubmodulesRecurse,
+		Teams:            owners != nil,
+		Modules:          projectModules,
 		EngineerAnalysis: engineerFlag,
 		EngineerOpts: git.EngineerOptions{
 			PeriodMonths: engineerMonthsFlag,
+			Rev:          revCommit,
+			Commits:      prog.CommitCounter(),
 		},
 	})
 
+	prog.Stop()
+	logWarnings(warnings)
+	if reason := partialReason(ctx); reason != "" {
+		report.Meta.Partial = true
+		report.Meta.PartialReason = reason
+		stopped := "interrupted"
+		if reason == "timeout" {
+			stopped = "timed out after " + timeoutFlag.String()
+		}
+		fmt.Fprintf(os.Stderr, "warning: %s, results are partial\n", stopped)
+	}
+
 	// Select renderer
 	opts := renderer.Options{
 		Writer:     os.Stdout,
 		NoColor:    noColorFlag || renderer.ShouldDisableColor(),
 		Pretty:     prettyFlag,
 		NoEmbedded: noEmbeddedFlag,
+		GroupBy:    byFlag,
 	}
 
 	// Engineer mode uses separate render path (replaces standard output)
@@ -246,6 +370,198 @@ func run(cmd *cobra.Command, args []string) error {
 	return r.Render(report)
 }
 
+// project is an analysis root with its aloc.yaml loaded and applied
+type project struct {
+	cfg         *config.Config
+	rules       *inference.RuleSet
+	submodules  scanner.SubmoduleMode
+	headerProbe bool
+}
+
+// loadProject loads the config for absRoot (or --config), registers its
+// languages and roles, and validates its rules
+func loadProject(absRoot string) (*project, error) {
+	// Load config
+	var cfg *config.Config
+	var err error
+	if configFlag != "" {
+		cfg, err = config.Load(configFlag)
+	} else {
+		cfg, err = config.LoadFromDir(absRoot)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	// Merge user-defined languages over the embedded table
+	if err := scanner.RegisterLanguages(languageConfigs(cfg.Languages)); err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	// Declare user-defined roles before anything refers to them
+	if err := model.RegisterRoles(roleDefs(cfg.Roles)); err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+	for role := range cfg.Overrides {
+		if !model.IsRole(role) {
+			return nil, fmt.Errorf("config error: overrides: unknown role %q", role)
+		}
+	}
+
+	submoduleMode := cfg.Options.Submodules
+	if submodulesFlag != "" {
+		submoduleMode = submodulesFlag
+	}
+	submodules, err := scanner.ParseSubmoduleMode(submoduleMode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Custom classification rules are merged into the built-in ones
+	rules, err := inference.NewRuleSet(customRules(cfg.Rules))
+	if err != nil {
+		return nil, fmt.Errorf("config error: %w", err)
+	}
+
+	return &project{
+		cfg:        cfg,
+		rules:      rules,
+		submodules: submodules,
+		// Header probing reads each file's leading bytes during the scan itself
+		headerProbe: deepFlag || headerProbeFlag || cfg.Options.HeaderProbe,
+	}, nil
+}
+
+func (p *project) scanOptions() scanner.Options {
+	return scanner.Options{
+		NumWorkers:        runtime.NumCPU() * 2,
+		Exclude:           p.cfg.Exclude,
+		DeepMode:          deepFlag,
+		IncludeExtensions: p.cfg.IncludeExtensions,
+		SkipExtensions:    p.cfg.SkipExtensions,
+		Submodules:        p.submodules,
+		HeaderProbe:       p.headerProbe,
+	}
+}
+
+func (p *project) engine() *inference.Engine {
+	return inference.NewEngine(inference.Options{
+		HeaderProbe:      p.headerProbe,
+		Neighborhood:     p.cfg.Options.Neighborhood,
+		Overrides:        p.cfg.Overrides,
+		Rules:            p.rules,
+		VendorSubmodules: p.submodules == scanner.SubmodulesVendor,
+	})
+}
+
+// partialReason explains why ctx was canceled: "timeout", "interrupted",
+// or "" while it is still live
+func partialReason(ctx context.Context) string {
+	switch {
+	case ctx.Err() == nil:
+		return ""
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "interrupted"
+	}
+}
+
+func logWarnings(warnings []error) {
+	for _, err := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
+
+// languageConfigs converts aloc.yaml language definitions for the scanner,
+// sorted by name so they are applied in a stable order
+func languageConfigs(defs map[string]config.Language) []scanner.LanguageConfig {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configs := make([]scanner.LanguageConfig, 0, len(defs))
+	for _, name := range names {
+		def := defs[name]
+		configs = append(configs, scanner.LanguageConfig{
+			Name:              name,
+			Extensions:        def.Extensions,
+			Filenames:         def.Filenames,
+			LineComment:       def.LineComment,
+			MultiLineComments: def.MultiLineComments,
+			Nested:            def.Nested,
+			Quotes:            def.Quotes,
+			Category:          def.Category,
+		})
+	}
+	return configs
+}
+
+// roleDefs converts aloc.yaml role declarations, sorted by name so roles
+// without an order are listed alphabetically
+func roleDefs(roles map[model.Role]config.Role) []model.RoleDef {
+	names := make([]model.Role, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	defs := make([]model.RoleDef, 0, len(roles))
+	for _, name := range names {
+		r := roles[name]
+		defs = append(defs, model.RoleDef{
+			Name:        name,
+			DisplayName: r.DisplayName,
+			Color:       model.SemanticColor(r.Color),
+			Order:       r.Order,
+			Core:        r.Core,
+		})
+	}
+	return defs
+}
+
+// customRules converts aloc.yaml rules for the inference engine, keeping
+// their order within each table
+func customRules(cfg config.Rules) []inference.CustomRule {
+	var rules []inference.CustomRule
+	for _, table := range []struct {
+		kind  inference.RuleKind
+		rules []config.Rule
+	}{
+		{inference.RuleKindPath, cfg.Path},
+		{inference.RuleKindFilename, cfg.Filename},
+		{inference.RuleKindExtension, cfg.Extension},
+		{inference.RuleKindHeader, cfg.Header},
+	} {
+		for _, r := range table.rules {
+			rules = append(rules, inference.CustomRule{
+				Kind:    table.kind,
+				Match:   r.Match,
+				Type:    r.Type,
+				Role:    r.Role,
+				SubRole: r.SubRole,
+				Weight:  r.Weight,
+				Disable: r.Disable,
+			})
+		}
+	}
+	return rules
+}
+
+// openCache opens the per-root scan cache, or returns nil when disabled
+func openCache(absRoot string) *scanner.Cache {
+	if noCacheFlag {
+		return nil
+	}
+	path, err := scanner.DefaultCachePath(absRoot)
+	if err != nil {
+		return nil // no cache directory (e.g. $HOME unset)
+	}
+	return scanner.OpenCache(path, version+"-"+commit)
+}
+
 // renderEngineerMode renders only the engineer throughput analysis
 func renderEngineerMode(report *model.Report, opts renderer.Options, format string) error {
 	if report.Engineer == nil {
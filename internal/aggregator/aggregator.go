@@ -1,6 +1,7 @@
 package aggregator
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"slices"
@@ -21,9 +22,20 @@ type Options struct {
 	GitOpts          git.Options
 	EngineerAnalysis bool
 	EngineerOpts     git.EngineerOptions
+	SubmoduleHistory bool           // merge the history of submodules found among the records
+	Teams            bool           // break LOC down by the records' CODEOWNERS teams
+	Modules          []model.Module // report each module's records separately
 }
 
-func Compute(records []*model.FileRecord, opts Options) *model.Report {
+// Compute builds the report. Canceling ctx stops git analysis; the report
+// then lacks git metrics.
+func Compute(ctx context.Context, records []*model.FileRecord, opts Options) *model.Report {
+	if opts.SubmoduleHistory {
+		nested := submodulePaths(records)
+		opts.GitOpts.Nested = nested
+		opts.EngineerOpts.Nested = nested
+	}
+
 	responsibilities := ComputeResponsibilities(records)
 
 	report := &model.Report{
@@ -39,6 +51,7 @@ func Compute(records []*model.FileRecord, opts Options) *model.Report {
 		Ratios:           ComputeRatios(responsibilities),
 		Languages:        ComputeLanguageBreakdown(records),
 		Confidence:       computeConfidenceInfo(records),
+		Untested:         ComputeUntestedSurface(records),
 	}
 
 	if opts.IncludeEffort {
@@ -51,15 +64,25 @@ func Compute(records []*model.FileRecord, opts Options) *model.Report {
 		)
 	}
 
+	if opts.Teams {
+		report.Teams = ComputeTeams(records)
+	}
+
+	if len(opts.Modules) > 0 {
+		report.Modules = computeModules(ctx, records, opts)
+	}
+
 	if opts.IncludeFiles {
 		report.Files = records
 	}
 
 	// git analysis (optional)
 	if opts.GitAnalysis && opts.RepoInfo != nil && opts.RepoInfo.Root != "" {
-		gitMetrics, err := git.Analyze(opts.RepoInfo.Root, records, opts.GitOpts)
+		gitMetrics, err := git.Analyze(ctx, opts.RepoInfo.Root, records, opts.GitOpts)
 		if err != nil {
-			log.Printf("git analysis: %v", err)
+			if ctx.Err() == nil {
+				log.Printf("git analysis: %v", err)
+			}
 		} else if gitMetrics != nil {
 			report.Git = convertGitMetrics(gitMetrics)
 
@@ -78,9 +101,11 @@ func Compute(records []*model.FileRecord, opts Options) *model.Report {
 
 	// engineer throughput analysis (optional, separate from git analysis)
 	if opts.EngineerAnalysis && opts.RepoInfo != nil && opts.RepoInfo.Root != "" {
-		engineerAnalysis, err := computeEngineerMetrics(opts.RepoInfo.Root, records, opts.EngineerOpts)
+		engineerAnalysis, err := computeEngineerMetrics(ctx, opts.RepoInfo.Root, records, opts.EngineerOpts)
 		if err != nil {
-			log.Printf("engineer analysis: %v", err)
+			if ctx.Err() == nil {
+				log.Printf("engineer analysis: %v", err)
+			}
 		} else if engineerAnalysis != nil {
 			report.Engineer = engineerAnalysis
 		}
@@ -89,13 +114,30 @@ func Compute(records []*model.FileRecord, opts Options) *model.Report {
 	return report
 }
 
+// submodulePaths lists the nested repositories the records were scanned from
+func submodulePaths(records []*model.FileRecord) []string {
+	var paths []string
+	for _, r := range records {
+		if r.Submodule != "" && !slices.Contains(paths, r.Submodule) {
+			paths = append(paths, r.Submodule)
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
 // computeEngineerMetrics runs engineer throughput analysis
-func computeEngineerMetrics(root string, records []*model.FileRecord, opts git.EngineerOptions) (*model.EngineerMetrics, error) {
+func computeEngineerMetrics(ctx context.Context, root string, records []*model.FileRecord, opts git.EngineerOptions) (*model.EngineerMetrics, error) {
 	// parse git history with author emails preserved
-	events, err := git.ParseHistory(git.ParseOptions{
+	opts.Until = git.WindowEnd(root, opts.Rev)
+	events, err := git.ParseHistory(ctx, git.ParseOptions{
 		SinceMonths:     opts.PeriodMonths,
 		Root:            root,
 		PreserveAuthors: true,
+		Rev:             opts.Rev,
+		Until:           opts.Until,
+		Nested:          opts.Nested,
+		Commits:         opts.Commits,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("parse git history: %w", err)
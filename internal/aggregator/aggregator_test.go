@@ -1,6 +1,8 @@
 package aggregator
 
 import (
+	"context"
+	"slices"
 	"testing"
 
 	"github.com/modern-tooling/aloc/internal/model"
@@ -138,6 +140,26 @@ func TestComputeLanguageBreakdown(t *testing.T) {
 	}
 }
 
+func TestComputeLanguageBreakdown_Docstrings(t *testing.T) {
+	records := []*model.FileRecord{
+		{Path: "a.py", LOC: 80, Lines: model.LineMetrics{Code: 80, Comments: 30, Docstrings: 25}, Language: "Python", Role: model.RoleCore},
+		{Path: "b.py", LOC: 20, Lines: model.LineMetrics{Code: 20, Comments: 10, Docstrings: 5}, Language: "Python", Role: model.RoleCore},
+	}
+
+	langs := ComputeLanguageBreakdown(records)
+	if langs[0].Docstrings != 30 {
+		t.Errorf("Python Docstrings = %v, want 30", langs[0].Docstrings)
+	}
+
+	summary := ComputeSummary(records)
+	if summary.Lines.Docstrings != 30 {
+		t.Errorf("Summary Docstrings = %v, want 30", summary.Lines.Docstrings)
+	}
+	if summary.Lines.Comments != 40 {
+		t.Errorf("Summary Comments = %v, want 40 (docstrings are included)", summary.Lines.Comments)
+	}
+}
+
 func TestComputeLanguageBreakdown_SignificantOnly(t *testing.T) {
 	records := []*model.FileRecord{
 		{Path: "a.go", LOC: 1000, Lines: model.LineMetrics{Code: 1000}, Language: "Go", Role: model.RoleCore},
@@ -158,7 +180,7 @@ func TestCompute(t *testing.T) {
 		{Path: "a_test.go", LOC: 50, Language: "Go", Role: model.RoleTest, Confidence: 0.95},
 	}
 
-	report := Compute(records, Options{IncludeFiles: true})
+	report := Compute(context.Background(), records, Options{IncludeFiles: true})
 
 	if report.Meta.Generator != "aloc" {
 		t.Errorf("Generator = %v, want aloc", report.Meta.Generator)
@@ -176,9 +198,181 @@ func TestCompute_ExcludeFiles(t *testing.T) {
 		{Path: "a.go", LOC: 100, Role: model.RoleCore},
 	}
 
-	report := Compute(records, Options{IncludeFiles: false})
+	report := Compute(context.Background(), records, Options{IncludeFiles: false})
 
 	if report.Files != nil {
 		t.Error("Files should be nil when IncludeFiles is false")
 	}
 }
+
+func TestCompute_Modules(t *testing.T) {
+	records := []*model.FileRecord{
+		{Path: "services/api/server.go", LOC: 400, Language: "Go", Role: model.RoleCore, Module: "services/api"},
+		{Path: "services/api/server_test.go", LOC: 200, Language: "Go", Role: model.RoleTest, Module: "services/api"},
+		{Path: "services/billing/charge.go", LOC: 300, Language: "Go", Role: model.RoleCore, Module: "services/billing"},
+		{Path: "README.md", LOC: 50, Language: "Markdown", Role: model.RoleDocs},
+	}
+	modules := []model.Module{
+		{Path: "services/api", Name: "example.com/api", Kind: "go"},
+		{Path: "services/billing", Name: "example.com/billing", Kind: "go"},
+		{Path: "services/empty", Name: "example.com/empty", Kind: "go"},
+	}
+
+	report := Compute(context.Background(), records, Options{
+		IncludeFiles: true,
+		Modules:      modules,
+		RepoInfo:     &model.RepoInfo{Name: "repo", Root: "/src/repo"},
+	})
+
+	if report.Summary.LOCTotal != 950 {
+		t.Errorf("LOCTotal = %v, want 950 (the repo total)", report.Summary.LOCTotal)
+	}
+	if len(report.Modules) != 2 {
+		t.Fatalf("Modules = %d, want 2 (modules without files are left out)", len(report.Modules))
+	}
+
+	api := report.Modules[0]
+	if api.Name != "example.com/api" || api.Report.Summary.LOCTotal != 600 || api.Report.Ratios.TestToCore != 0.5 {
+		t.Errorf("api = %s with %d LOC and test/core %v, want example.com/api, 600, 0.5",
+			api.Name, api.Report.Summary.LOCTotal, api.Report.Ratios.TestToCore)
+	}
+	if repo := api.Report.Meta.Repo; repo == nil || repo.Root != "/src/repo/services/api" {
+		t.Errorf("api Meta.Repo = %+v, want root /src/repo/services/api", repo)
+	}
+	if api.Report.Files != nil || api.Report.Modules != nil {
+		t.Error("module reports should list neither files nor modules")
+	}
+
+	if billing := report.Modules[1]; billing.Report.Ratios.TestToCore != 0 {
+		t.Errorf("billing TestToCore = %v, want 0", billing.Report.Ratios.TestToCore)
+	}
+	if untested := report.Modules[1].Report.Untested; untested == nil || untested.Files != 1 || untested.LOC != 300 {
+		t.Errorf("billing Untested = %+v, want charge.go's 300 LOC", untested)
+	}
+}
+
+func TestComputeRatios_CustomRoles(t *testing.T) {
+	if err := model.RegisterRoles([]model.RoleDef{
+		{Name: "migrations"},
+		{Name: "proto-contracts", Core: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { model.RegisterRoles(nil) })
+
+	resp := []model.Responsibility{
+		{Role: model.RoleCore, LOC: 800},
+		{Role: "proto-contracts", LOC: 200},
+		{Role: model.RoleTest, LOC: 500},
+		{Role: "migrations", LOC: 100},
+	}
+
+	ratios := ComputeRatios(resp)
+
+	// proto-contracts counts as core: 500 / (800 + 200)
+	if ratios.TestToCore != 0.5 {
+		t.Errorf("TestToCore = %v, want 0.5", ratios.TestToCore)
+	}
+	if got := ratios.CustomToCore["migrations"]; got != 0.1 || len(ratios.CustomToCore) != 1 {
+		t.Errorf("CustomToCore = %v, want only migrations at 0.1", ratios.CustomToCore)
+	}
+
+	breakdown := ComputeHybridBreakdown(resp, 16000)
+	var contracts *model.HybridSavings
+	for i := range breakdown {
+		if breakdown[i].Role == "proto-contracts" {
+			contracts = &breakdown[i]
+		}
+	}
+	if contracts == nil || contracts.Reduction != HybridReductionRates[model.RoleCore].Reduction {
+		t.Errorf("proto-contracts hybrid savings = %+v, want the core reduction rate", contracts)
+	}
+}
+
+func TestComputeTeams(t *testing.T) {
+	records := []*model.FileRecord{
+		{Path: "api/server.go", LOC: 400, Role: model.RoleCore, Owners: []string{"@acme/api"}},
+		{Path: "api/server_test.go", LOC: 200, Role: model.RoleTest, Owners: []string{"@acme/api"}},
+		{Path: "web/app.ts", LOC: 300, Role: model.RoleCore, Owners: []string{"@acme/web"}},
+		{Path: "e2e/checkout.spec.ts", LOC: 900, Role: model.RoleTest, Owners: []string{"@acme/qa"}},
+		{Path: "README.md", LOC: 1000, Role: model.RoleDocs},
+	}
+
+	teams := ComputeTeams(records)
+
+	var owners []string
+	for _, team := range teams {
+		owners = append(owners, team.Owner)
+	}
+	if want := []string{"@acme/qa", "@acme/api", "@acme/web", ""}; !slices.Equal(owners, want) {
+		t.Fatalf("teams = %q, want %q (by LOC, unowned last)", owners, want)
+	}
+
+	api := teams[1]
+	if api.LOC != 600 || api.Files != 2 || api.ByRole[model.RoleTest] != 200 {
+		t.Errorf("api = %+v, want 600 LOC in 2 files, 200 test", api)
+	}
+	if api.TestToCore != 0.5 {
+		t.Errorf("api TestToCore = %v, want 0.5", api.TestToCore)
+	}
+	if qa := teams[0]; qa.TestToCore != 0 {
+		t.Errorf("qa TestToCore = %v, want 0 (no core code)", qa.TestToCore)
+	}
+}
+
+func TestComputeTeams_CoOwned(t *testing.T) {
+	records := []*model.FileRecord{
+		{Path: "api/server.go", LOC: 400, Role: model.RoleCore, Owners: []string{"@acme/api"}},
+		{Path: "api/auth.go", LOC: 100, Role: model.RoleCore, Owners: []string{"@acme/api", "@acme/security"}},
+	}
+
+	teams := ComputeTeams(records)
+	if len(teams) != 2 {
+		t.Fatalf("teams = %+v, want api and security", teams)
+	}
+	if api := teams[0]; api.Owner != "@acme/api" || api.LOC != 500 || api.Files != 2 || api.SharedLOC != 100 {
+		t.Errorf("api = %+v, want 500 LOC in 2 files, 100 shared", api)
+	}
+	if sec := teams[1]; sec.Owner != "@acme/security" || sec.LOC != 100 || sec.SharedLOC != 100 {
+		t.Errorf("security = %+v, want the co-owned 100 LOC in full", sec)
+	}
+}
+
+func TestComputeUntestedSurface(t *testing.T) {
+	records := []*model.FileRecord{
+		{Path: "api/server.go", Language: "Go", LOC: 400, Role: model.RoleCore},
+		{Path: "api/server_test.go", Language: "Go", LOC: 200, Role: model.RoleTest, SubRole: model.TestUnit},
+		{Path: "api/routes.go", Language: "Go", LOC: 100, Role: model.RoleCore},
+		{Path: "api/middleware.go", Language: "Go", LOC: 250, Role: model.RoleCore},
+		{Path: "store/db.go", Language: "Go", LOC: 50, Role: model.RoleCore},
+		{Path: "store/helpers_test.go", Language: "Go", LOC: 30, Role: model.RoleTest, SubRole: model.TestUnit},
+		{Path: "store/testdata/db_test.go", Language: "Go", LOC: 10, Role: model.RoleTest, SubRole: model.TestFixture},
+		{Path: "README.md", Language: "Markdown", LOC: 1000, Role: model.RoleDocs},
+	}
+
+	surface := ComputeUntestedSurface(records)
+	if surface == nil {
+		t.Fatal("ComputeUntestedSurface = nil, want a surface")
+	}
+	if surface.Files != 3 || surface.LOC != 400 || surface.CoreFiles != 4 || surface.CoreLOC != 800 {
+		t.Errorf("surface = %+v, want 3 of 4 core files, 400 of 800 LOC", surface)
+	}
+	if surface.Tests != 2 || surface.PairedTests != 1 {
+		t.Errorf("tests = %d paired of %d, want 1 of 2 (fixtures excluded)", surface.PairedTests, surface.Tests)
+	}
+
+	if len(surface.Directories) != 2 {
+		t.Fatalf("directories = %+v, want api and store", surface.Directories)
+	}
+	api := surface.Directories[0]
+	if api.Dir != "api" || api.LOC != 350 || api.CoreLOC != 750 {
+		t.Errorf("api = %+v, want 350 of 750 LOC untested", api)
+	}
+	if want := []string{"api/middleware.go", "api/routes.go"}; !slices.Equal(api.Files, want) {
+		t.Errorf("api files = %v, want %v (largest first)", api.Files, want)
+	}
+
+	if ComputeUntestedSurface(records[7:]) != nil {
+		t.Error("ComputeUntestedSurface without core code, want nil")
+	}
+}
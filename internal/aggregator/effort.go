@@ -168,6 +168,9 @@ func ComputeHybridBreakdown(responsibilities []model.Responsibility, totalHumanC
 
 	for _, r := range responsibilities {
 		rate, ok := HybridReductionRates[r.Role]
+		if !ok && r.Role.CountsAsCore() {
+			rate, ok = HybridReductionRates[model.RoleCore], true
+		}
 		if !ok || r.LOC == 0 {
 			continue
 		}
@@ -193,13 +196,7 @@ func ComputeQuickActions(responsibilities []model.Responsibility, ratios model.R
 	var actions []model.QuickAction
 
 	// Find core LOC for gap calculations
-	var coreLOC int
-	for _, r := range responsibilities {
-		if r.Role == model.RoleCore {
-			coreLOC = r.LOC
-			break
-		}
-	}
+	coreLOC := coreLOCOf(responsibilities)
 
 	if coreLOC == 0 {
 		return actions
@@ -13,6 +13,7 @@ type langAccum struct {
 	Files    int
 	Code     int // code lines (line type)
 	Comments int // comment lines (line type)
+	Docs     int // docstring lines (subset of Comments)
 	Blanks   int // blank lines (line type)
 	Tests    int // test LOC (role-based, subset of Code)
 	Config   int // config LOC
@@ -41,6 +42,7 @@ func ComputeLanguageBreakdown(records []*model.FileRecord) []model.LanguageComp
 		// Track line types (Code + Comments + Blanks = Total)
 		acc.Code += r.Lines.Code
 		acc.Comments += r.Lines.Comments
+		acc.Docs += r.Lines.Docstrings
 		acc.Blanks += r.Lines.Blanks
 		acc.LOCTotal += r.Lines.Code + r.Lines.Comments + r.Lines.Blanks
 		acc.Files++
@@ -57,10 +59,7 @@ func ComputeLanguageBreakdown(records []*model.FileRecord) []model.LanguageComp
 		// Accumulate embedded code block stats (for Markdown, etc.)
 		for lang, metrics := range r.Embedded {
 			existing := acc.Embedded[lang]
-			existing.Total += metrics.Total
-			existing.Code += metrics.Code
-			existing.Comments += metrics.Comments
-			existing.Blanks += metrics.Blanks
+			existing.Add(metrics)
 			acc.Embedded[lang] = existing
 		}
 	}
@@ -88,6 +87,7 @@ func ComputeLanguageBreakdown(records []*model.FileRecord) []model.LanguageComp
 			Files:            acc.Files,
 			Code:             acc.Code,
 			Comments:         acc.Comments,
+			Docstrings:       acc.Docs,
 			Blanks:           acc.Blanks,
 			Tests:            acc.Tests,
 			Config:           acc.Config,
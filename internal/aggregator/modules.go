@@ -0,0 +1,47 @@
+package aggregator
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// computeModules builds a report for each module from the records assigned
+// to it. Module reports carry no git metrics, files or nested modules;
+// modules without counted files are left out.
+func computeModules(ctx context.Context, records []*model.FileRecord, opts Options) []model.ModuleReport {
+	byModule := make(map[string][]*model.FileRecord)
+	for _, r := range records {
+		if r.Module != "" {
+			byModule[r.Module] = append(byModule[r.Module], r)
+		}
+	}
+
+	moduleOpts := opts
+	moduleOpts.Modules = nil
+	moduleOpts.IncludeFiles = false
+	moduleOpts.GitAnalysis = false
+	moduleOpts.EngineerAnalysis = false
+	moduleOpts.SubmoduleHistory = false
+	moduleOpts.RepoInfo = nil // no git hint either
+
+	var reports []model.ModuleReport
+	for _, m := range opts.Modules {
+		moduleRecords := byModule[m.Path]
+		if len(moduleRecords) == 0 {
+			continue
+		}
+		report := Compute(ctx, moduleRecords, moduleOpts)
+		report.Meta.Repo = &model.RepoInfo{Name: m.Name}
+		if opts.RepoInfo != nil {
+			report.Meta.Repo.Commit = opts.RepoInfo.Commit
+			report.Meta.Repo.Branch = opts.RepoInfo.Branch
+			if opts.RepoInfo.Root != "" {
+				report.Meta.Repo.Root = filepath.Join(opts.RepoInfo.Root, filepath.FromSlash(m.Path))
+			}
+		}
+		reports = append(reports, model.ModuleReport{Module: m, Report: report})
+	}
+	return reports
+}
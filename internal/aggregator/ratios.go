@@ -8,16 +8,39 @@ func ComputeRatios(responsibilities []model.Responsibility) model.Ratios {
 		byRole[r.Role] = r.LOC
 	}
 
-	coreLOC := byRole[model.RoleCore]
+	// user-defined roles can count as core
+	coreLOC := coreLOCOf(responsibilities)
 	if coreLOC == 0 {
 		coreLOC = 1 // avoid division by zero
 	}
 
-	return model.Ratios{
+	ratios := model.Ratios{
 		TestToCore:      float32(byRole[model.RoleTest]) / float32(coreLOC),
 		InfraToCore:     float32(byRole[model.RoleInfra]) / float32(coreLOC),
 		DocsToCore:      float32(byRole[model.RoleDocs]) / float32(coreLOC),
 		GeneratedToCore: float32(byRole[model.RoleGenerated]) / float32(coreLOC),
 		ConfigToCore:    float32(byRole[model.RoleConfig]) / float32(coreLOC),
 	}
+	for _, role := range model.Roles() {
+		loc, ok := byRole[role]
+		if !ok || !role.IsCustom() || role.CountsAsCore() {
+			continue
+		}
+		if ratios.CustomToCore == nil {
+			ratios.CustomToCore = make(map[model.Role]float32)
+		}
+		ratios.CustomToCore[role] = float32(loc) / float32(coreLOC)
+	}
+	return ratios
+}
+
+// coreLOCOf sums the LOC of core and of the roles counted as core
+func coreLOCOf(responsibilities []model.Responsibility) int {
+	var loc int
+	for _, r := range responsibilities {
+		if r.Role.CountsAsCore() {
+			loc += r.LOC
+		}
+	}
+	return loc
 }
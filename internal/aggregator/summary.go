@@ -9,10 +9,7 @@ func ComputeSummary(records []*model.FileRecord) model.Summary {
 
 	for _, r := range records {
 		totalLOC += r.LOC
-		lines.Total += r.Lines.Total
-		lines.Blanks += r.Lines.Blanks
-		lines.Comments += r.Lines.Comments
-		lines.Code += r.Lines.Code
+		lines.Add(r.Lines)
 		if r.Language != "" && r.Language != "unknown" {
 			langs[r.Language] = true
 		}
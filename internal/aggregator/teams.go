@@ -0,0 +1,59 @@
+package aggregator
+
+import (
+	"sort"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// ComputeTeams breaks LOC down by the CODEOWNERS teams of each record,
+// largest team first and unowned files last. A co-owned file counts in full
+// for each of its owners, so team totals can add up to more than the LOC.
+func ComputeTeams(records []*model.FileRecord) []model.TeamBreakdown {
+	byOwner := make(map[string]*model.TeamBreakdown)
+	for _, r := range records {
+		owners := r.Owners
+		if len(owners) == 0 {
+			owners = []string{""}
+		}
+		for _, owner := range owners {
+			team, ok := byOwner[owner]
+			if !ok {
+				team = &model.TeamBreakdown{Owner: owner, ByRole: make(map[model.Role]int)}
+				byOwner[owner] = team
+			}
+			team.LOC += r.LOC
+			team.Files++
+			team.ByRole[r.Role] += r.LOC
+			if len(owners) > 1 {
+				team.SharedLOC += r.LOC
+			}
+		}
+	}
+
+	teams := make([]model.TeamBreakdown, 0, len(byOwner))
+	for _, team := range byOwner {
+		var coreLOC int
+		for role, loc := range team.ByRole {
+			if role.CountsAsCore() {
+				coreLOC += loc
+			}
+		}
+		// a team without core code has no meaningful ratio
+		if coreLOC > 0 {
+			team.TestToCore = float32(team.ByRole[model.RoleTest]) / float32(coreLOC)
+		}
+		teams = append(teams, *team)
+	}
+
+	sort.Slice(teams, func(i, j int) bool {
+		if (teams[i].Owner == "") != (teams[j].Owner == "") {
+			return teams[j].Owner == ""
+		}
+		if teams[i].LOC != teams[j].LOC {
+			return teams[i].LOC > teams[j].LOC
+		}
+		return teams[i].Owner < teams[j].Owner
+	})
+	return teams
+}
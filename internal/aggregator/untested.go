@@ -0,0 +1,70 @@
+package aggregator
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/pairing"
+)
+
+// ComputeUntestedSurface pairs tests with core files and collects the core
+// files no test pairs with, by directory. It returns nil without core code.
+func ComputeUntestedSurface(records []*model.FileRecord) *model.UntestedSurface {
+	tested := make(map[*model.FileRecord]bool)
+	pairedTests := make(map[*model.FileRecord]bool)
+	for _, p := range pairing.Pairs(records) {
+		tested[p.Source] = true
+		pairedTests[p.Test] = true
+	}
+
+	surface := &model.UntestedSurface{PairedTests: len(pairedTests), Directories: []model.UntestedDir{}}
+	byDir := make(map[string]*model.UntestedDir)
+	var untested []*model.FileRecord
+	for _, r := range records {
+		if r.Role == model.RoleTest && r.SubRole != model.TestFixture {
+			surface.Tests++
+		}
+		if !pairing.IsSurface(r) {
+			continue
+		}
+		surface.CoreFiles++
+		surface.CoreLOC += r.LOC
+
+		dir := filepath.Dir(r.Path)
+		d, ok := byDir[dir]
+		if !ok {
+			d = &model.UntestedDir{Dir: dir}
+			byDir[dir] = d
+		}
+		d.CoreLOC += r.LOC
+		if !tested[r] {
+			surface.Files++
+			surface.LOC += r.LOC
+			d.LOC += r.LOC
+			untested = append(untested, r)
+		}
+	}
+	if surface.CoreFiles == 0 {
+		return nil
+	}
+
+	sort.SliceStable(untested, func(i, j int) bool { return untested[i].LOC > untested[j].LOC })
+	for _, r := range untested {
+		d := byDir[filepath.Dir(r.Path)]
+		d.Files = append(d.Files, r.Path)
+	}
+	for _, d := range byDir {
+		if d.LOC > 0 {
+			surface.Directories = append(surface.Directories, *d)
+		}
+	}
+	sort.Slice(surface.Directories, func(i, j int) bool {
+		a, b := surface.Directories[i], surface.Directories[j]
+		if a.LOC != b.LOC {
+			return a.LOC > b.LOC
+		}
+		return a.Dir < b.Dir
+	})
+	return surface
+}
@@ -0,0 +1,193 @@
+// Package codeowners reads GitHub CODEOWNERS files and assigns each file
+// the team that owns it.
+package codeowners
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/git"
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// Locations are where GitHub looks for a CODEOWNERS file, relative to the
+// repository's top level; the first one found is used
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners is a parsed CODEOWNERS file. Like GitHub, the last rule
+// matching a file decides its owners.
+type CodeOwners struct {
+	File   string // the location the rules were read from
+	prefix string // the analyzed root's path within the repository
+	rules  []rule
+}
+
+type rule struct {
+	pattern  string
+	anchored bool
+	dirOnly  bool
+	owners   []string // none: the file is explicitly unowned
+}
+
+// Load reads the CODEOWNERS file of the repository holding root. It returns
+// nil when there is none. Outside a git repository root is taken as the
+// top level.
+func Load(root string) (*CodeOwners, error) {
+	top, prefix, err := git.RepoTop(root)
+	if err != nil {
+		top, prefix = root, ""
+	}
+	for _, loc := range Locations {
+		f, err := os.Open(filepath.Join(top, filepath.FromSlash(loc)))
+		if err != nil {
+			if os.IsNotExist(err) || os.IsPermission(err) {
+				continue
+			}
+			return nil, err
+		}
+		defer f.Close()
+		return parse(f, loc, prefix)
+	}
+	return nil, nil
+}
+
+// LoadRev reads the CODEOWNERS file of rev, or returns nil when it has none
+func LoadRev(root, rev string) (*CodeOwners, error) {
+	_, prefix, err := git.RepoTop(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, loc := range Locations {
+		data, err := git.ReadFile(root, rev, loc)
+		if err != nil {
+			continue // not in the tree
+		}
+		return parse(bytes.NewReader(data), loc, prefix)
+	}
+	return nil, nil
+}
+
+// Parse parses CODEOWNERS rules for a root at the repository's top level
+func Parse(r io.Reader) (*CodeOwners, error) {
+	return parse(r, "", "")
+}
+
+func parse(src io.Reader, file, prefix string) (*CodeOwners, error) {
+	co := &CodeOwners{File: file, prefix: prefix}
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		// a leading "\" escapes a literal "#"
+		r := rule{pattern: strings.TrimPrefix(fields[0], `\`)}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break // trailing comment
+			}
+			r.owners = append(r.owners, owner)
+		}
+
+		if strings.HasSuffix(r.pattern, "/") {
+			r.dirOnly = true
+			r.pattern = strings.TrimSuffix(r.pattern, "/")
+		}
+		// as in .gitignore, a slash anywhere but the end anchors the
+		// pattern to the top level
+		if strings.HasPrefix(r.pattern, "/") {
+			r.anchored = true
+			r.pattern = r.pattern[1:]
+		} else if strings.Contains(r.pattern, "/") {
+			r.anchored = true
+		}
+		co.rules = append(co.rules, r)
+	}
+	return co, scanner.Err()
+}
+
+// Owners returns the owners of a root-relative file path, or nil when no
+// rule assigns any
+func (co *CodeOwners) Owners(relPath string) []string {
+	if co == nil {
+		return nil
+	}
+	relPath = co.prefix + filepath.ToSlash(relPath)
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].matches(relPath) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Owner returns the owning team of a root-relative file path: the first
+// owner of the last matching rule, or "" when the file is unowned
+func (co *CodeOwners) Owner(relPath string) string {
+	if owners := co.Owners(relPath); len(owners) > 0 {
+		return owners[0]
+	}
+	return ""
+}
+
+// Assign sets the owners of every record
+func (co *CodeOwners) Assign(records []*model.FileRecord) {
+	for _, r := range records {
+		r.Owners = co.Owners(r.Path)
+	}
+}
+
+// matches reports whether the rule matches a file or one of its parent
+// directories. GitHub makes one exception to .gitignore semantics: a
+// trailing "/*" matches a directory's files but not those of its
+// subdirectories.
+func (r rule) matches(file string) bool {
+	parts := strings.Split(file, "/")
+	for i := range parts {
+		last := i == len(parts)-1
+		if (r.dirOnly && last) || (!last && strings.HasSuffix(r.pattern, "/*")) {
+			continue
+		}
+		if r.anchored {
+			if matchSegments(strings.Split(r.pattern, "/"), parts[:i+1]) {
+				return true
+			}
+		} else if ok, _ := path.Match(r.pattern, parts[i]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches glob segments against path segments, where a "**"
+// segment matches zero or more directories
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		// trailing "/**" matches everything inside, but not the directory itself
+		if len(pattern) == 1 {
+			return len(parts) > 0
+		}
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,147 @@
+package codeowners
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestCodeOwners_Owner(t *testing.T) {
+	co, err := Parse(strings.NewReader(`# default owners
+*       @acme/platform
+
+*.js    @acme/web @alice   # frontend
+/build/logs/ @acme/ops
+docs/*  @acme/docs
+apps/   @acme/apps
+**/migrations @acme/data
+\#notes.md @acme/notes
+
+# explicitly unowned
+/apps/legacy/
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "@acme/platform"},
+		{"web/app.js", "@acme/web"},           // last match wins over *
+		{"build/logs/today.txt", "@acme/ops"}, // anchored directory covers its contents
+		{"pkg/build/logs/today.txt", "@acme/platform"},
+		{"docs/index.md", "@acme/docs"},
+		{"docs/guide/setup.md", "@acme/platform"}, // docs/* covers one level only
+		{"apps/api/server.go", "@acme/apps"},      // unanchored directory at the top
+		{"services/apps/api.go", "@acme/apps"},    // and at any depth
+		{"apps/legacy/old.go", ""},                // a rule without owners unowns
+		{"db/migrations/0001.sql", "@acme/data"},
+		{"#notes.md", "@acme/notes"},
+	}
+
+	for _, tt := range tests {
+		if got := co.Owner(tt.path); got != tt.want {
+			t.Errorf("Owner(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if got := co.Owners("web/app.js"); len(got) != 2 || got[1] != "@alice" {
+		t.Errorf("Owners(web/app.js) = %v, want [@acme/web @alice]", got)
+	}
+
+	var none *CodeOwners
+	if got := none.Owner("main.go"); got != "" {
+		t.Errorf("nil CodeOwners Owner = %q, want \"\"", got)
+	}
+}
+
+func TestCodeOwners_Assign(t *testing.T) {
+	co, err := Parse(strings.NewReader("*  @acme/platform\n/api/auth/ @acme/api @acme/security\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []*model.FileRecord{{Path: "main.go"}, {Path: "api/auth/login.go"}}
+	co.Assign(records)
+
+	if got := records[0].Owners; !slices.Equal(got, []string{"@acme/platform"}) {
+		t.Errorf("main.go owners = %v, want [@acme/platform]", got)
+	}
+	if got := records[1].Owners; !slices.Equal(got, []string{"@acme/api", "@acme/security"}) {
+		t.Errorf("login.go owners = %v, want both co-owners", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	if co, err := Load(repo); err != nil || co != nil {
+		t.Fatalf("Load without CODEOWNERS = %v, %v; want nil, nil", co, err)
+	}
+
+	// .github/ takes precedence over the top level and docs/
+	write("CODEOWNERS", "* @acme/root\n")
+	write(".github/CODEOWNERS", "* @acme/github\n/services/api/ @acme/api\n")
+	write("services/api/main.go", "package main\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	co, err := Load(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if co.File != ".github/CODEOWNERS" || co.Owner("README.md") != "@acme/github" {
+		t.Errorf("Load used %s (owner %q), want .github/CODEOWNERS", co.File, co.Owner("README.md"))
+	}
+
+	// patterns stay relative to the top level when a subdirectory is analyzed
+	co, err = Load(filepath.Join(repo, "services"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := co.Owner("api/main.go"); got != "@acme/api" {
+		t.Errorf("Owner(api/main.go) below services = %q, want @acme/api", got)
+	}
+
+	// a revision is read from its tree, not the working copy
+	write(".github/CODEOWNERS", "* @acme/changed\n")
+	co, err = LoadRev(repo, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := co.Owner("README.md"); got != "@acme/github" {
+		t.Errorf("LoadRev Owner = %q, want @acme/github", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/modern-tooling/aloc/internal/model"
@@ -16,7 +17,11 @@ const (
 
 // EngineerOptions controls engineer throughput analysis
 type EngineerOptions struct {
-	PeriodMonths int // analysis window (default 6)
+	PeriodMonths int           // analysis window (default 6)
+	Rev          string        // analyze history up to this revision (default HEAD)
+	Until        time.Time     // end of the window (default now)
+	Nested       []string      // submodules whose own history is merged in
+	Commits      *atomic.Int64 // commits read so far, for progress reporting
 }
 
 // CalculateEngineerStats computes per-contributor throughput metrics
@@ -31,7 +36,10 @@ func CalculateEngineerStats(events []ChangeEvent, opts EngineerOptions) *Enginee
 	}
 
 	// calculate the analysis window
-	now := time.Now()
+	now := opts.Until
+	if now.IsZero() {
+		now = time.Now()
+	}
 	windowStart := now.AddDate(0, -periodMonths, 0)
 
 	// aggregate by author email (core+test LOC)
@@ -53,7 +61,7 @@ func CalculateEngineerStats(events []ChangeEvent, opts EngineerOptions) *Enginee
 		}
 
 		// count core + test role files (production code and tests)
-		if e.Role != model.RoleCore && e.Role != model.RoleTest {
+		if !e.Role.CountsAsCore() && e.Role != model.RoleTest {
 			continue
 		}
 
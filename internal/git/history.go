@@ -2,11 +2,15 @@ package git
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/modern-tooling/aloc/internal/model"
@@ -14,31 +18,89 @@ import (
 
 // ParseOptions controls git log parsing
 type ParseOptions struct {
-	SinceMonths     int    // how far back to look
-	Root            string // repository root
-	PreserveAuthors bool   // keep raw emails for engineer analysis
+	SinceMonths     int           // how far back to look
+	Root            string        // repository root
+	PreserveAuthors bool          // keep raw emails for engineer analysis
+	Rev             string        // revision to start from (default HEAD)
+	Until           time.Time     // end of the window (default now)
+	Nested          []string      // root-relative submodules whose history is merged in
+	Commits         *atomic.Int64 // incremented as commits are read, for progress reporting
 }
 
-// ParseHistory runs git log and returns change events
-func ParseHistory(opts ParseOptions) ([]ChangeEvent, error) {
-	since := time.Now().AddDate(0, -opts.SinceMonths, 0).Format("2006-01-02")
+// ParseHistory runs git log and returns change events. Canceling ctx kills
+// the git process.
+func ParseHistory(ctx context.Context, opts ParseOptions) ([]ChangeEvent, error) {
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	since := until.AddDate(0, -opts.SinceMonths, 0).Format("2006-01-02")
 
 	// single efficient git command
 	// format: hash|email|timestamp followed by commit body (for AI marker detection)
 	// %x00 separates header from body, %x01 marks end of body
-	cmd := exec.Command("git", "-C", opts.Root,
+	args := []string{"-C", opts.Root,
 		"log",
 		"--numstat",
 		"--format=%H|%ae|%aI%x00%b%x01",
-		"--since="+since,
-	)
+		"--since=" + since,
+	}
+	if opts.Rev != "" {
+		args = append(args, opts.Rev)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
 
-	out, err := cmd.Output()
-	if err != nil {
+	var out strings.Builder
+	cmd.Stdout = &commitCounter{w: &out, commits: opts.Commits}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 
-	return parseGitLog(string(out), opts.PreserveAuthors), nil
+	events := parseGitLog(out.String(), opts.PreserveAuthors)
+	for _, dir := range opts.Nested {
+		events = append(events, parseNestedHistory(ctx, opts, dir)...)
+	}
+	return events, nil
+}
+
+// commitCounter counts the commits streaming through git log's output by
+// their end-of-body markers
+type commitCounter struct {
+	w       *strings.Builder
+	commits *atomic.Int64
+}
+
+func (c *commitCounter) Write(p []byte) (int, error) {
+	if c.commits != nil {
+		c.commits.Add(int64(bytes.Count(p, []byte{'\x01'})))
+	}
+	return c.w.Write(p)
+}
+
+// parseNestedHistory reads the history of a submodule or nested checkout,
+// with paths prefixed so they match the scanned files. A nested repository
+// without commits contributes no events.
+func parseNestedHistory(ctx context.Context, opts ParseOptions, dir string) []ChangeEvent {
+	nested := ParseOptions{
+		SinceMonths:     opts.SinceMonths,
+		Root:            filepath.Join(opts.Root, dir),
+		PreserveAuthors: opts.PreserveAuthors,
+		Until:           opts.Until,
+		Commits:         opts.Commits,
+	}
+	events, err := ParseHistory(ctx, nested)
+	if err != nil {
+		return nil
+	}
+
+	prefix := filepath.ToSlash(dir) + "/"
+	for i := range events {
+		events[i].Path = prefix + events[i].Path
+	}
+	return events
 }
 
 // parseGitLog parses the git log output into change events
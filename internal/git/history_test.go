@@ -1,6 +1,13 @@
 package git
 
-import "testing"
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
 
 func TestDetectAIMarker(t *testing.T) {
 	tests := []struct {
@@ -69,3 +76,95 @@ func TestDetectAIMarker(t *testing.T) {
 		})
 	}
 }
+
+func TestParseHistory_Nested(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), ".gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	root := t.TempDir()
+	commit := func(dir, file, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", file}, {"commit", "-q", "-m", "add " + file}} {
+			cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+
+	nested := filepath.Join(root, "libs", "auth")
+	for _, dir := range []string{root, nested} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+			t.Fatalf("git init: %v\n%s", err, out)
+		}
+	}
+	commit(root, "main.go", "package main\n")
+	commit(nested, "auth.go", "package auth\n\nfunc Login() {}\n")
+
+	events, err := ParseHistory(context.Background(), ParseOptions{SinceMonths: 1, Root: root, Nested: []string{"libs/auth"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := make(map[string]int)
+	for _, ev := range events {
+		paths[ev.Path] += ev.Added
+	}
+	if paths["main.go"] != 1 || paths["libs/auth/auth.go"] != 3 || len(paths) != 2 {
+		t.Errorf("changed paths = %v, want main.go and libs/auth/auth.go", paths)
+	}
+}
+
+func TestParseHistory_RevWindow(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), ".gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	root := t.TempDir()
+	run := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("", "init", "-q")
+	for _, c := range []struct{ file, date string }{
+		{"old.go", "2020-01-15T12:00:00Z"},
+		{"new.go", "2024-06-01T12:00:00Z"},
+	} {
+		if err := os.WriteFile(filepath.Join(root, c.file), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run(c.date, "add", c.file)
+		run(c.date, "commit", "-q", "-m", "add "+c.file)
+		if c.file == "old.go" {
+			run(c.date, "tag", "v1")
+		}
+	}
+
+	until := WindowEnd(root, "v1")
+	if want := time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC); !until.Equal(want) {
+		t.Errorf("WindowEnd(v1) = %v, want %v", until, want)
+	}
+
+	// the window ends at the rev's commit, not today
+	events, err := ParseHistory(context.Background(), ParseOptions{SinceMonths: 1, Root: root, Rev: "v1", Until: until})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Path != "old.go" {
+		t.Errorf("events at v1 = %+v, want old.go", events)
+	}
+}
@@ -1,7 +1,10 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/modern-tooling/aloc/internal/model"
@@ -9,9 +12,12 @@ import (
 
 // Options controls git analysis behavior
 type Options struct {
-	SparklineMonths int  // months of history for sparklines (default 6)
-	StabilityMonths int  // months threshold for stable code (default 18)
-	Smooth          bool // use bi-weekly buckets instead of weekly
+	SparklineMonths int           // months of history for sparklines (default 6)
+	StabilityMonths int           // months threshold for stable code (default 18)
+	Smooth          bool          // use bi-weekly buckets instead of weekly
+	Rev             string        // analyze history up to this revision (default HEAD)
+	Nested          []string      // submodules whose own history is merged in
+	Commits         *atomic.Int64 // commits read so far, for progress reporting
 }
 
 // DefaultOptions returns sensible defaults
@@ -24,7 +30,7 @@ func DefaultOptions() Options {
 }
 
 // Analyze performs full git history analysis
-func Analyze(root string, records []*model.FileRecord, opts Options) (*GitMetrics, error) {
+func Analyze(ctx context.Context, root string, records []*model.FileRecord, opts Options) (*GitMetrics, error) {
 	if opts.SparklineMonths == 0 {
 		opts.SparklineMonths = 6
 	}
@@ -34,11 +40,16 @@ func Analyze(root string, records []*model.FileRecord, opts Options) (*GitMetric
 
 	// use longer window for stability analysis
 	historyMonths := max(opts.StabilityMonths, opts.SparklineMonths)
+	now := WindowEnd(root, opts.Rev)
 
 	// parse git history
-	events, err := ParseHistory(ParseOptions{
+	events, err := ParseHistory(ctx, ParseOptions{
 		SinceMonths: historyMonths,
 		Root:        root,
+		Rev:         opts.Rev,
+		Until:       now,
+		Nested:      opts.Nested,
+		Commits:     opts.Commits,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("parse git history: %w", err)
@@ -56,11 +67,16 @@ func Analyze(root string, records []*model.FileRecord, opts Options) (*GitMetric
 	MapRoles(events, records)
 	fileLOC := BuildFileLOCMap(records)
 
-	now := time.Now()
+	// submodule files without merged history would all look untouched
+	for _, r := range records {
+		if r.Submodule != "" && !slices.Contains(opts.Nested, r.Submodule) {
+			delete(fileLOC, r.Path)
+		}
+	}
 
 	// compute metrics
 	churnStat := CalculateChurnConcentration(events)
-	stableCore, volatileSurface := CalculateStability(events, fileLOC, opts.StabilityMonths)
+	stableCore, volatileSurface := CalculateStability(events, fileLOC, opts.StabilityMonths, now)
 	rewritePressure := CalculateRewritePressure(events)
 	ownershipConc := CalculateOwnershipConcentration(events, fileLOC)
 	parallelism := CalculateParallelismSignal(events)
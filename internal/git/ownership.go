@@ -1,9 +1,5 @@
 package git
 
-import (
-	"github.com/modern-tooling/aloc/internal/model"
-)
-
 // CalculateOwnershipConcentration computes % of prod LOC owned by single author
 func CalculateOwnershipConcentration(events []ChangeEvent, fileLOC map[string]int) float64 {
 	// count changes per file per author (already hashed)
@@ -15,7 +11,7 @@ func CalculateOwnershipConcentration(events []ChangeEvent, fileLOC map[string]in
 	fileTotal := make(map[string]int)
 
 	for _, ev := range events {
-		if ev.Role != model.RoleCore {
+		if !ev.Role.CountsAsCore() {
 			continue // only prod code
 		}
 		key := fileAuthor{ev.Path, ev.Author}
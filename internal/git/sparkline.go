@@ -1,6 +1,7 @@
 package git
 
 import (
+	"slices"
 	"strings"
 	"time"
 
@@ -80,14 +81,14 @@ func ChurnSparkline(events []ChangeEvent, role model.Role, now time.Time, months
 func BuildChurnSeries(events []ChangeEvent, now time.Time, months int, smooth bool) map[model.Role]*Sparkline {
 	series := make(map[model.Role]*Sparkline)
 
-	// core roles to track
-	roles := []model.Role{
-		model.RoleCore,
-		model.RoleTest,
-		model.RoleInfra,
-	}
-
-	for _, role := range roles {
+	// core, test and infra, plus user-defined roles that saw changes
+	for _, role := range model.Roles() {
+		switch {
+		case role == model.RoleCore || role == model.RoleTest || role == model.RoleInfra:
+		case role.IsCustom() && slices.ContainsFunc(events, func(ev ChangeEvent) bool { return ev.Role == role }):
+		default:
+			continue
+		}
 		series[role] = ChurnSparkline(events, role, now, months, smooth)
 	}
 
@@ -3,8 +3,8 @@ package git
 import "time"
 
 // CalculateStability computes stable core and volatile surface percentages
-func CalculateStability(events []ChangeEvent, fileLOC map[string]int, stableMonths int) (stableCore, volatileSurface float64) {
-	now := time.Now()
+// as of now
+func CalculateStability(events []ChangeEvent, fileLOC map[string]int, stableMonths int, now time.Time) (stableCore, volatileSurface float64) {
 	stableCutoff := now.AddDate(0, -stableMonths, 0)
 	volatileCutoff := now.AddDate(0, -6, 0)
 
@@ -0,0 +1,162 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TreeEntry is a regular file in a git tree
+type TreeEntry struct {
+	Path   string // relative to the directory the tree was listed from
+	Object string // blob object id
+	Size   int64
+}
+
+// ResolveRev resolves a commit, tag or branch name to a commit id
+func ResolveRev(root, rev string) (string, error) {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q", rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CommitTime returns the committer date of rev
+func CommitTime(root, rev string) (time.Time, error) {
+	out, err := exec.Command("git", "-C", root, "show", "-s", "--format=%cI", rev).Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("git show %s: %w", rev, err)
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+}
+
+// WindowEnd returns when history windows end: the commit date of rev, so an
+// old revision is measured against its own recent history, or now for the
+// working tree (or when the date can't be read)
+func WindowEnd(root, rev string) time.Time {
+	if rev != "" {
+		if t, err := CommitTime(root, rev); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// ListTree lists the regular files of rev below root, with paths relative to
+// root. Submodules and symlinks are skipped.
+func ListTree(root, rev string) ([]TreeEntry, error) {
+	out, err := exec.Command("git", "-C", root, "ls-tree", "-r", "-l", "-z", rev).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree %s: %w", rev, err)
+	}
+	return parseLsTree(out), nil
+}
+
+// parseLsTree parses `git ls-tree -r -l -z` output:
+// <mode> SP <type> SP <object> SP <size> TAB <path> NUL
+func parseLsTree(out []byte) []TreeEntry {
+	var entries []TreeEntry
+	for _, record := range bytes.Split(out, []byte{0}) {
+		meta, path, ok := strings.Cut(string(record), "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" || fields[0] == "120000" {
+			continue // submodule (commit) or symlink
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, TreeEntry{Path: path, Object: fields[2], Size: size})
+	}
+	return entries
+}
+
+// BlobReader reads blob contents through a long-running `git cat-file --batch`
+// process. It is not safe for concurrent use.
+type BlobReader struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// NewBlobReader starts a cat-file process for the repository at root
+func NewBlobReader(root string) (*BlobReader, error) {
+	cmd := exec.Command("git", "-C", root, "cat-file", "--batch")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	return &BlobReader{cmd: cmd, stdin: stdin, stdout: bufio.NewReaderSize(stdout, 256*1024)}, nil
+}
+
+// Read returns the contents of a blob
+func (b *BlobReader) Read(object string) ([]byte, error) {
+	if _, err := fmt.Fprintln(b.stdin, object); err != nil {
+		return nil, err
+	}
+
+	// header: <object> SP <type> SP <size> LF, or <object> SP missing LF
+	header, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("git cat-file: object %s %s", object, strings.Join(fields[1:], " "))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: bad header %q", header)
+	}
+
+	// contents are followed by a LF
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(b.stdout, data); err != nil {
+		return nil, err
+	}
+	return data[:size], nil
+}
+
+// Close stops the cat-file process
+func (b *BlobReader) Close() error {
+	b.stdin.Close()
+	return b.cmd.Wait()
+}
+
+// RepoTop returns the top level of the repository holding root, and root's
+// path within it: "" at the top level, otherwise slash-separated with a
+// trailing slash
+func RepoTop(root string) (top, prefix string, err error) {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "--show-toplevel", "--show-prefix").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("not a git repository: %s", root)
+	}
+	top, prefix, _ = strings.Cut(strings.TrimRight(string(out), "\n"), "\n")
+	return top, prefix, nil
+}
+
+// ReadFile returns the contents of path, relative to the repository's top
+// level, at rev
+func ReadFile(root, rev, path string) ([]byte, error) {
+	out, err := exec.Command("git", "-C", root, "cat-file", "blob", rev+":"+path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file %s:%s: %w", rev, path, err)
+	}
+	return out, nil
+}
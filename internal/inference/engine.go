@@ -1,72 +1,126 @@
 package inference
 
 import (
-	"os"
-	"path/filepath"
-	"strings"
+	"fmt"
+	"runtime"
+	"sync"
 
 	"github.com/modern-tooling/aloc/internal/model"
 )
 
 type Engine struct {
-	overrides         *Overrides
-	enableHeaderProbe bool
+	overrides          *Overrides
+	rules              *RuleSet
+	enableHeaderProbe  bool
 	enableNeighborhood bool
+	vendorSubmodules   bool
+	workers            int
 }
 
 type Options struct {
-	HeaderProbe   bool
-	Neighborhood  bool
-	Overrides     map[model.Role][]string
+	HeaderProbe      bool
+	Neighborhood     bool
+	Overrides        map[model.Role][]string
+	Rules            *RuleSet // weighted rules; the built-in ones when nil
+	VendorSubmodules bool     // classify files in submodules and nested checkouts as vendor
+	Workers          int      // parallelism of InferBatch, GOMAXPROCS by default
 }
 
 func NewEngine(opts Options) *Engine {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.GOMAXPROCS(0)
+	}
+	rules := opts.Rules
+	if rules == nil {
+		rules = DefaultRuleSet()
+	}
 	var overrides *Overrides
 	if opts.Overrides != nil {
 		overrides = NewOverrides(opts.Overrides)
 	}
 	return &Engine{
 		overrides:          overrides,
+		rules:              rules,
 		enableHeaderProbe:  opts.HeaderProbe,
 		enableNeighborhood: opts.Neighborhood,
+		vendorSubmodules:   opts.VendorSubmodules,
+		workers:            opts.Workers,
 	}
 }
 
 func (e *Engine) Infer(file *model.RawFile) *model.FileRecord {
+	return e.infer(file, nil)
+}
+
+// infer classifies a file, recording the reasoning in trace when it is set
+func (e *Engine) infer(file *model.RawFile, trace *Explanation) *model.FileRecord {
 	score := NewRoleScore()
+	score.trace = trace
 
 	// 1. Check overrides first (weight 1.0)
 	if e.overrides != nil {
 		if override := e.overrides.Match(file.Path); override != nil {
-			score.Add(override.Role, 1.0, model.SignalOverride)
+			score.hit(Hit{Signal: model.SignalOverride, Rule: fmt.Sprintf("override %q", override.Pattern), Role: override.Role, Weight: 1.0, Custom: true})
+			score.note("override matched; no other rules are consulted")
 			return e.buildRecord(file, score)
 		}
 	}
 
+	// Submodules are someone else's code, like vendor/ (weight 1.0)
+	if e.vendorSubmodules && file.Submodule != "" {
+		score.hit(Hit{Signal: model.SignalSubmodule, Rule: fmt.Sprintf("submodule %q", file.Submodule), Role: model.RoleVendor, Weight: 1.0})
+		score.note("file is in a submodule; no other rules are consulted")
+		return e.buildRecord(file, score)
+	}
+
+	// linguist-* attributes from .gitattributes
+	applyLinguistRules(file, score)
+
 	// 2. Apply path rules
-	applyPathRules(file.Path, score)
+	e.rules.applyPath(file.Path, score)
 
 	// 3. Apply filename rules
-	applyFilenameRules(file.Path, score)
+	e.rules.applyFilename(file.Path, score)
+
+	// 4. Minified and bundled files are generated wherever they live
+	applyMinifiedRules(file, score)
 
-	// 4. Apply extension bias (only if not already decisive)
+	// 5. Apply extension bias (only if not already decisive)
 	if score.MaxWeight() < 0.50 {
-		applyExtensionRules(file.Path, score)
+		e.rules.applyExtension(file.Path, score)
+	} else {
+		score.note("extension rules skipped: a role already has weight %.2f (>= 0.50)", score.MaxWeight())
+	}
+
+	// 6. Apply header probe (optional)
+	// (the header is captured by the scanner; files scanned without header
+	// probing have none)
+	switch {
+	case !e.enableHeaderProbe:
+		score.note("header rules skipped: header probing is off (--header-probe or --deep)")
+	case score.MaxWeight() >= 0.80:
+		score.note("header rules skipped: a role already has weight %.2f (>= 0.80)", score.MaxWeight())
+	case len(file.Header) == 0:
+		score.note("header rules skipped: no header captured")
+	default:
+		e.rules.applyHeader(string(file.Header), score)
+	}
+
+	// 7. A test kind marker the scanner found anywhere in the file refines
+	// the kind of a test, whatever the weights; it doesn't vote for a role
+	if file.TestMarker.Kind != "" {
+		applyTestMarker(file.TestMarker, score)
 	}
 
-	// 5. Apply header probe (optional)
-	if e.enableHeaderProbe && score.MaxWeight() < 0.80 {
-		applyHeaderRules(file.Path, score)
+	if file.LanguageFromContent {
+		score.note("language %s detected from file content", file.LanguageHint)
 	}
 
 	return e.buildRecord(file, score)
 }
 
 func (e *Engine) InferBatch(files []*model.RawFile) []*model.FileRecord {
-	records := make([]*model.FileRecord, len(files))
-	for i, f := range files {
-		records[i] = e.Infer(f)
-	}
+	records := e.inferAll(files)
 
 	// Second pass: neighborhood inference
 	if e.enableNeighborhood {
@@ -76,8 +130,32 @@ func (e *Engine) InferBatch(files []*model.RawFile) []*model.FileRecord {
 	return records
 }
 
+// inferAll is the first, per-file pass of InferBatch
+func (e *Engine) inferAll(files []*model.RawFile) []*model.FileRecord {
+	records := make([]*model.FileRecord, len(files))
+
+	// Infer is independent per file; each worker takes a contiguous chunk
+	chunk := (len(files) + e.workers - 1) / e.workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(files); start += chunk {
+		end := min(start+chunk, len(files))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				records[i] = e.Infer(files[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return records
+}
+
 func (e *Engine) buildRecord(file *model.RawFile, score *RoleScore) *model.FileRecord {
 	role, subRole, confidence, signals := score.Resolve()
+	if file.LanguageFromContent {
+		signals = append(signals, model.SignalContent)
+	}
 	return &model.FileRecord{
 		Path:       file.Path,
 		LOC:        file.LOC,
@@ -88,121 +166,80 @@ func (e *Engine) buildRecord(file *model.RawFile, score *RoleScore) *model.FileR
 		Confidence: confidence,
 		Signals:    signals,
 		Embedded:   file.Embedded,
+		Submodule:  file.Submodule,
 	}
 }
 
-func applyPathRules(path string, score *RoleScore) {
-	lowerPath := strings.ToLower(path)
-	for _, rule := range PathRules {
-		if strings.Contains(lowerPath, rule.Fragment) {
-			score.Add(rule.Role, rule.Weight, model.SignalPath)
-		}
-	}
-}
-
-func applyFilenameRules(path string, score *RoleScore) {
-	filename := strings.ToLower(filepath.Base(path))
-	for _, rule := range FilenameRules {
-		var matched bool
-		switch rule.MatchType {
-		case "suffix":
-			matched = strings.HasSuffix(filename, strings.ToLower(rule.Pattern))
-		case "prefix":
-			matched = strings.HasPrefix(filename, strings.ToLower(rule.Pattern))
-		case "contains":
-			matched = strings.Contains(filename, strings.ToLower(rule.Pattern))
+func applyLinguistRules(file *model.RawFile, score *RoleScore) {
+	for _, rule := range LinguistRules {
+		var set bool
+		switch rule.Attribute {
+		case "linguist-generated":
+			set = file.Linguist.Generated
+		case "linguist-vendored":
+			set = file.Linguist.Vendored
+		case "linguist-documentation":
+			set = file.Linguist.Documentation
 		}
-		if matched {
-			score.AddWithSubRole(rule.Role, rule.SubRole, rule.Weight, model.SignalFilename)
+		if set {
+			score.hit(Hit{Signal: model.SignalAttributes, Rule: ".gitattributes " + rule.Attribute, Role: rule.Role, Weight: rule.Weight})
 		}
 	}
-}
-
-func applyExtensionRules(path string, score *RoleScore) {
-	ext := strings.ToLower(filepath.Ext(path))
-	// Check compound extensions like .pb.go
-	base := filepath.Base(path)
-	for _, rule := range ExtensionRules {
-		if strings.HasSuffix(strings.ToLower(base), rule.Ext) || ext == rule.Ext {
-			score.Add(rule.Role, rule.Weight, model.SignalExtension)
-		}
+	if file.Linguist.Language != "" {
+		score.note("language %s set by linguist-language in .gitattributes", file.Linguist.Language)
 	}
 }
 
-func applyHeaderRules(path string, score *RoleScore) {
-	header, err := readHeader(path, 2048)
-	if err != nil {
+func applyMinifiedRules(file *model.RawFile, score *RoleScore) {
+	shape := file.Shape
+	if file.Lines.Total == 0 || file.Bytes == 0 {
+		return
+	}
+	if shape.SourceMap {
+		score.hit(Hit{Signal: model.SignalMinified, Rule: "sourceMappingURL trailer", Role: model.RoleGenerated, Weight: sourceMapWeight})
 		return
 	}
-	content := string(header)
-	for _, rule := range HeaderRules {
-		if strings.Contains(content, rule.Pattern) {
-			score.Add(rule.Role, rule.Weight, model.SignalHeader)
+
+	avgLine := int(file.Bytes) / file.Lines.Total
+	whitespace := float32(shape.Whitespace) / float32(file.Bytes)
+	for _, rule := range MinifiedRules {
+		if shape.MaxLineLength >= rule.MaxLineLength && avgLine >= rule.AvgLineLength && whitespace <= rule.MaxWhitespace {
+			score.hit(Hit{
+				Signal: model.SignalMinified,
+				Rule:   fmt.Sprintf("longest line %d >= %d, average %d >= %d, whitespace %.0f%% <= %.0f%%", shape.MaxLineLength, rule.MaxLineLength, avgLine, rule.AvgLineLength, whitespace*100, rule.MaxWhitespace*100),
+				Role:   model.RoleGenerated,
+				Weight: rule.Weight,
+			})
+			return
 		}
 	}
 }
 
-func readHeader(path string, maxBytes int) ([]byte, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// applyTestMarker sets the test kind from a marker, for files with a vote
+// for test not named for another kind than unit (x.e2e.ts, a fixture)
+func applyTestMarker(marker model.TestMarker, score *RoleScore) {
+	if score.Weights[model.RoleTest] == 0 {
+		return
 	}
-	defer f.Close()
-
-	buf := make([]byte, maxBytes)
-	n, _ := f.Read(buf)
-	return buf[:n], nil
+	if kind := score.SubRoles[model.RoleTest]; kind != "" && kind != model.TestUnit {
+		return
+	}
+	score.SubRoles[model.RoleTest] = marker.Kind
+	score.note("test kind %s: marker %q", marker.Kind, marker.Line)
 }
 
 func applyNeighborhoodInference(records []*model.FileRecord) {
-	// Group by directory
-	byDir := make(map[string][]*model.FileRecord)
-	for _, r := range records {
-		dir := filepath.Dir(r.Path)
-		byDir[dir] = append(byDir[dir], r)
-	}
-
-	for _, dirRecords := range byDir {
-		if len(dirRecords) < 3 {
-			continue
-		}
-
-		// Count role distribution
-		roleCounts := make(map[model.Role]int)
-		var total int
+	for dir, dirRecords := range groupByDir(records) {
+		// Apply the dominant role to low-confidence files
+		vote := voteNeighborhood(dir, dirRecords)
 		for _, r := range dirRecords {
-			if r.Confidence >= 0.70 {
-				roleCounts[r.Role]++
-				total++
-			}
-		}
-
-		if total < 2 {
-			continue
-		}
-
-		// Find dominant role
-		var dominantRole model.Role
-		var dominantCount int
-		for role, count := range roleCounts {
-			if count > dominantCount {
-				dominantCount = count
-				dominantRole = role
-			}
-		}
-
-		// Apply to low-confidence files
-		dominantRatio := float32(dominantCount) / float32(total)
-		if dominantRatio >= 0.70 {
-			for _, r := range dirRecords {
-				if r.Confidence < 0.60 && r.Role != dominantRole {
-					r.Role = dominantRole
-					r.Confidence = r.Confidence + 0.40
-					if r.Confidence > 1.0 {
-						r.Confidence = 1.0
-					}
-					r.Signals = append(r.Signals, model.SignalNeighborhood)
+			if vote.reassigns(r) {
+				r.Role = vote.Dominant
+				r.Confidence = r.Confidence + 0.40
+				if r.Confidence > 1.0 {
+					r.Confidence = 1.0
 				}
+				r.Signals = append(r.Signals, model.SignalNeighborhood)
 			}
 		}
 	}
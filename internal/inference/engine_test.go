@@ -1,6 +1,8 @@
 package inference
 
 import (
+	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/modern-tooling/aloc/internal/model"
@@ -1197,3 +1199,194 @@ func TestEngineInfer_PbDir(t *testing.T) {
 		t.Errorf("Role = %v, want generated (pb directory)", record.Role)
 	}
 }
+
+func TestEngineInfer_ContentSignal(t *testing.T) {
+	engine := NewEngine(Options{})
+
+	record := engine.Infer(&model.RawFile{
+		Path:                "src/solve.m",
+		LanguageHint:        "MATLAB",
+		LanguageFromContent: true,
+	})
+
+	found := false
+	for _, s := range record.Signals {
+		if s == model.SignalContent {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Signals = %v, want content signal", record.Signals)
+	}
+}
+
+func TestEngineInfer_MinifiedFile(t *testing.T) {
+	engine := NewEngine(Options{})
+
+	tests := []struct {
+		name string
+		file *model.RawFile
+		want bool
+	}{
+		{
+			name: "minified asset",
+			file: &model.RawFile{Path: "src/vendor-lib.js", Bytes: 40000, Lines: model.LineMetrics{Total: 4, Code: 4},
+				Shape: model.TextShape{MaxLineLength: 30000, Whitespace: 900}},
+			want: true,
+		},
+		{
+			name: "bundle with whitespace",
+			file: &model.RawFile{Path: "src/bundle.js", Bytes: 500000, Lines: model.LineMetrics{Total: 2000, Code: 2000},
+				Shape: model.TextShape{MaxLineLength: 120000, Whitespace: 60000}},
+			want: true,
+		},
+		{
+			name: "source map trailer",
+			file: &model.RawFile{Path: "lib/index.js", Bytes: 800, Lines: model.LineMetrics{Total: 30, Code: 29, Comments: 1},
+				Shape: model.TextShape{MaxLineLength: 60, Whitespace: 150, SourceMap: true}},
+			want: true,
+		},
+		{
+			name: "hand-written code",
+			file: &model.RawFile{Path: "src/app.js", Bytes: 3000, Lines: model.LineMetrics{Total: 100, Code: 90},
+				Shape: model.TextShape{MaxLineLength: 110, Whitespace: 700}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := engine.Infer(tt.file)
+			if got := record.Role == model.RoleGenerated; got != tt.want {
+				t.Errorf("Role = %v, want generated = %v", record.Role, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineInfer_Submodule(t *testing.T) {
+	file := &model.RawFile{Path: "libs/auth/auth.go", LOC: 10, Submodule: "libs/auth"}
+
+	record := NewEngine(Options{VendorSubmodules: true}).Infer(file)
+	if record.Role != model.RoleVendor || record.Submodule != "libs/auth" {
+		t.Errorf("vendored submodule: Role = %v, Submodule = %q; want vendor, libs/auth", record.Role, record.Submodule)
+	}
+	if len(record.Signals) == 0 || record.Signals[0] != model.SignalSubmodule {
+		t.Errorf("Signals = %v, want submodule", record.Signals)
+	}
+
+	// recursive analysis classifies submodule files like any other
+	record = NewEngine(Options{}).Infer(file)
+	if record.Role != model.RoleCore {
+		t.Errorf("recursive submodule: Role = %v, want core", record.Role)
+	}
+}
+
+func TestEngineInfer_HeaderProbe(t *testing.T) {
+	engine := NewEngine(Options{HeaderProbe: true})
+
+	// the header comes from the scanner; the file itself is never opened
+	file := &model.RawFile{
+		Path:         "/nonexistent/internal/api/client.go",
+		LOC:          300,
+		LanguageHint: "Go",
+		Header:       []byte("// Code generated by oapi-codegen. DO NOT EDIT.\n\npackage api\n"),
+	}
+	if record := engine.Infer(file); record.Role != model.RoleGenerated {
+		t.Errorf("Role = %v, want generated", record.Role)
+	}
+
+	file.Header = nil
+	if record := engine.Infer(file); record.Role != model.RoleCore {
+		t.Errorf("Role without header = %v, want core", record.Role)
+	}
+}
+
+func TestEngineInfer_TestKindMarkers(t *testing.T) {
+	// markers come from the scanner, so they apply without header probing
+	engine := NewEngine(Options{})
+
+	integration := model.TestMarker{Kind: model.TestIntegration, Line: "//go:build integration"}
+	tests := []struct {
+		path   string
+		marker model.TestMarker
+		want   model.TestKind
+	}{
+		{"/project/store/db_test.go", integration, model.TestIntegration},
+		{"/project/store/db_test.go", model.TestMarker{Kind: model.TestFuzz, Line: "func FuzzParse(f *testing.F) {"}, model.TestFuzz},
+		{"/project/store/db_test.go", model.TestMarker{}, model.TestUnit},
+		// the filename names a kind already
+		{"/project/web/login.e2e.ts", model.TestMarker{Kind: model.TestUnit, Line: "describe('login', () => {"}, model.TestE2E},
+		{"/project/store/db_bench_test.go", integration, model.TestBenchmark},
+	}
+
+	for _, tt := range tests {
+		file := &model.RawFile{Path: tt.path, LOC: 100, TestMarker: tt.marker}
+		record := engine.Infer(file)
+		if record.Role != model.RoleTest || record.SubRole != tt.want {
+			t.Errorf("Infer(%s, %+v) = %v/%v, want test/%v", tt.path, tt.marker, record.Role, record.SubRole, tt.want)
+		}
+	}
+
+	// markers don't make a test of core code
+	file := &model.RawFile{Path: "/project/store/db.go", LOC: 100, LanguageHint: "Go", TestMarker: integration}
+	if record := engine.Infer(file); record.Role != model.RoleCore || record.SubRole != "" {
+		t.Errorf("core file with build tag = %v/%v, want core", record.Role, record.SubRole)
+	}
+}
+
+func TestEngineInferBatch_ParallelKeepsOrder(t *testing.T) {
+	engine := NewEngine(Options{Workers: 4})
+
+	var files []*model.RawFile
+	for i := range 1001 {
+		path := fmt.Sprintf("/project/src/file%d.go", i)
+		if i%3 == 0 {
+			path = fmt.Sprintf("/project/src/file%d_test.go", i)
+		}
+		files = append(files, &model.RawFile{Path: path, LOC: i, LanguageHint: "Go"})
+	}
+
+	records := engine.InferBatch(files)
+	if len(records) != len(files) {
+		t.Fatalf("Records count = %v, want %v", len(records), len(files))
+	}
+	for i, r := range records {
+		want := model.RoleCore
+		if i%3 == 0 {
+			want = model.RoleTest
+		}
+		if r.Path != files[i].Path || r.Role != want {
+			t.Errorf("records[%d] = %s (%v), want %s (%v)", i, r.Path, r.Role, files[i].Path, want)
+		}
+	}
+}
+
+func TestEngineInfer_LinguistAttributes(t *testing.T) {
+	engine := NewEngine(Options{})
+
+	tests := []struct {
+		path     string
+		linguist model.Linguist
+		want     model.Role
+	}{
+		{"/project/api/client.go", model.Linguist{Generated: true}, model.RoleGenerated},
+		{"/project/lib/zlib/inflate.c", model.Linguist{Vendored: true}, model.RoleVendor},
+		{"/project/guides/setup.go", model.Linguist{Documentation: true}, model.RoleDocs},
+		// outweighs a filename rule
+		{"/project/api/client_test.go", model.Linguist{Generated: true}, model.RoleGenerated},
+		{"/project/api/client.go", model.Linguist{Language: "Go"}, model.RoleCore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			record := engine.Infer(&model.RawFile{Path: tt.path, LOC: 100, LanguageHint: "Go", Linguist: tt.linguist})
+			if record.Role != tt.want {
+				t.Errorf("Role = %v, want %v", record.Role, tt.want)
+			}
+			if tt.want != model.RoleCore && !slices.Contains(record.Signals, model.SignalAttributes) {
+				t.Errorf("Signals = %v, want gitattributes", record.Signals)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package inference
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// Explanation is the reasoning behind one file's classification: every rule
+// that fired, how the weights resolved, and the neighborhood vote
+type Explanation struct {
+	Path         string            `json:"path"`
+	Language     string            `json:"language"`
+	Hits         []Hit             `json:"hits"`
+	Notes        []string          `json:"notes,omitempty"`   // rule stages that were skipped, and why
+	Weights      []RoleWeight      `json:"weights,omitempty"` // per role, in resolution order
+	Ambiguous    bool              `json:"ambiguous"`         // top two roles within 0.15; confidence x0.8
+	Agreement    float32           `json:"agreement"`         // confidence factor for the winning role's signal count
+	Initial      Resolution        `json:"initial"`           // before the neighborhood pass
+	Neighborhood *NeighborhoodVote `json:"neighborhood,omitempty"`
+	Result       *model.FileRecord `json:"result"`
+}
+
+// Hit is a rule that fired for a file
+type Hit struct {
+	Signal  model.Signal   `json:"signal"`
+	Rule    string         `json:"rule"`
+	Role    model.Role     `json:"role"`
+	SubRole model.TestKind `json:"sub_role,omitempty"`
+	Weight  float32        `json:"weight"`
+	Custom  bool           `json:"custom,omitempty"` // from aloc.yaml
+	Match   string         `json:"match,omitempty"`  // the header line a header rule matched
+}
+
+// Resolution is a role as resolved from the rule weights
+type Resolution struct {
+	Role       model.Role     `json:"role"`
+	SubRole    model.TestKind `json:"sub_role,omitempty"`
+	Confidence float32        `json:"confidence"`
+}
+
+// NeighborhoodVote is how the confidently classified files (confidence
+// >= 0.70) of a directory voted in the neighborhood pass
+type NeighborhoodVote struct {
+	Dir      string             `json:"dir"`
+	Files    int                `json:"files"`
+	Votes    map[model.Role]int `json:"votes"`
+	Dominant model.Role         `json:"dominant,omitempty"`
+	Share    float32            `json:"share"` // the dominant role's share of the votes
+	Applied  bool               `json:"applied"`
+	Reason   string             `json:"reason"`
+}
+
+// Explain classifies files exactly like InferBatch and returns the
+// reasoning for those explain selects, in input order
+func (e *Engine) Explain(files []*model.RawFile, explain func(path string) bool) []*Explanation {
+	records := e.inferAll(files)
+
+	var explanations []*Explanation
+	for i, file := range files {
+		if !explain(file.Path) {
+			continue
+		}
+		x := &Explanation{Path: file.Path, Language: file.LanguageHint, Hits: []Hit{}}
+		e.infer(file, x)
+		r := records[i]
+		x.Initial = Resolution{Role: r.Role, SubRole: r.SubRole, Confidence: r.Confidence}
+		x.Result = r
+		explanations = append(explanations, x)
+	}
+
+	if e.enableNeighborhood {
+		// votes are counted from the first pass, before any file moves
+		byDir := groupByDir(records)
+		for _, x := range explanations {
+			dir := filepath.Dir(x.Path)
+			vote := voteNeighborhood(dir, byDir[dir])
+			vote.Applied, vote.Reason = vote.explain(x.Result)
+			x.Neighborhood = &vote
+		}
+		applyNeighborhoodInference(records)
+	}
+	return explanations
+}
+
+// addRule adds a weighted rule's vote; header is the probed header text
+// for header rules
+func (s *RoleScore) addRule(r rule, signal model.Signal, header string) {
+	s.AddWithSubRole(r.role, r.subRole, r.weight, signal)
+	if s.trace == nil {
+		return
+	}
+	h := Hit{Signal: signal, Rule: r.describe(signal), Role: r.role, SubRole: r.subRole, Weight: r.weight, Custom: r.custom}
+	if signal == model.SignalHeader {
+		h.Match = r.matchedLine(header)
+	}
+	s.trace.Hits = append(s.trace.Hits, h)
+}
+
+// hit records a vote that didn't come from a weighted rule
+func (s *RoleScore) hit(h Hit) {
+	s.Add(h.Role, h.Weight, h.Signal)
+	if s.trace != nil {
+		s.trace.Hits = append(s.trace.Hits, h)
+	}
+}
+
+// note records why a rule stage was skipped, when explaining
+func (s *RoleScore) note(format string, args ...any) {
+	if s.trace != nil {
+		s.trace.Notes = append(s.trace.Notes, fmt.Sprintf(format, args...))
+	}
+}
+
+func groupByDir(records []*model.FileRecord) map[string][]*model.FileRecord {
+	byDir := make(map[string][]*model.FileRecord)
+	for _, r := range records {
+		dir := filepath.Dir(r.Path)
+		byDir[dir] = append(byDir[dir], r)
+	}
+	return byDir
+}
+
+// voteNeighborhood counts the roles of a directory's confidently
+// classified files
+func voteNeighborhood(dir string, dirRecords []*model.FileRecord) NeighborhoodVote {
+	vote := NeighborhoodVote{Dir: dir, Files: len(dirRecords), Votes: make(map[model.Role]int)}
+	var total int
+	for _, r := range dirRecords {
+		if r.Confidence >= 0.70 {
+			vote.Votes[r.Role]++
+			total++
+		}
+	}
+
+	var dominantCount int
+	for role, count := range vote.Votes {
+		if count > dominantCount || (count == dominantCount && rolePriority(role) < rolePriority(vote.Dominant)) {
+			dominantCount = count
+			vote.Dominant = role
+		}
+	}
+	if total > 0 {
+		vote.Share = float32(dominantCount) / float32(total)
+	}
+	return vote
+}
+
+func (v NeighborhoodVote) voters() int {
+	var total int
+	for _, count := range v.Votes {
+		total += count
+	}
+	return total
+}
+
+// decisive reports whether the vote is strong enough to move files
+func (v NeighborhoodVote) decisive() bool {
+	return v.Files >= 3 && v.voters() >= 2 && v.Share >= 0.70
+}
+
+// reassigns reports whether the vote moves a file to the dominant role
+func (v NeighborhoodVote) reassigns(r *model.FileRecord) bool {
+	return v.decisive() && r.Confidence < 0.60 && r.Role != v.Dominant
+}
+
+// explain says whether and why the vote moves a first-pass record
+func (v NeighborhoodVote) explain(r *model.FileRecord) (bool, string) {
+	switch {
+	case v.Files < 3:
+		return false, fmt.Sprintf("only %s in the directory (needs 3)", plural(v.Files, "file"))
+	case v.voters() < 2:
+		return false, fmt.Sprintf("only %s (needs 2)", plural(v.voters(), "confidently classified file"))
+	case v.Share < 0.70:
+		return false, fmt.Sprintf("no role has 70%% of the votes (%s has %.0f%%)", v.Dominant, v.Share*100)
+	case r.Confidence >= 0.60:
+		return false, fmt.Sprintf("confidence %.2f is not below 0.60", r.Confidence)
+	case r.Role == v.Dominant:
+		return false, fmt.Sprintf("already classified as %s", v.Dominant)
+	default:
+		return true, fmt.Sprintf("moved from %s to %s, confidence +0.40", r.Role, v.Dominant)
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,134 @@
+package inference
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestEngineExplain(t *testing.T) {
+	rules, err := NewRuleSet([]CustomRule{
+		{Kind: RuleKindPath, Match: "/acceptance/", Role: model.RoleTest, SubRole: model.TestE2E, Weight: 0.70},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(Options{
+		Neighborhood: true,
+		HeaderProbe:  true,
+		Rules:        rules,
+		Overrides:    map[model.Role][]string{model.RoleDocs: {"**/*.gen.md"}},
+	})
+
+	files := []*model.RawFile{
+		{Path: "/project/acceptance/checkout_test.go", LOC: 100, LanguageHint: "Go"},
+		{Path: "/project/api/client.go", LOC: 100, LanguageHint: "Go", Header: []byte("// Code generated by oapi-codegen. DO NOT EDIT.\npackage api\n")},
+		{Path: "/project/api/notes.gen.md", LOC: 10, LanguageHint: "Markdown"},
+		{Path: "/project/main.go", LOC: 100, LanguageHint: "Go"},
+	}
+
+	explanations := engine.Explain(files, func(path string) bool { return !strings.HasSuffix(path, "main.go") })
+	if len(explanations) != 3 {
+		t.Fatalf("explanations = %d, want 3", len(explanations))
+	}
+
+	// Explain classifies exactly like InferBatch
+	records := engine.InferBatch(files)
+	for i, x := range explanations {
+		if x.Result.Role != records[i].Role || x.Result.Confidence != records[i].Confidence {
+			t.Errorf("%s: Explain = %v %.2f, InferBatch = %v %.2f", x.Path, x.Result.Role, x.Result.Confidence, records[i].Role, records[i].Confidence)
+		}
+	}
+
+	acceptance := explanations[0]
+	if len(acceptance.Hits) != 2 {
+		t.Fatalf("acceptance hits = %+v, want the custom path rule and the _test. filename rule", acceptance.Hits)
+	}
+	if h := acceptance.Hits[0]; h.Rule != `path contains "/acceptance/"` || !h.Custom || h.SubRole != model.TestE2E {
+		t.Errorf("acceptance.Hits[0] = %+v", h)
+	}
+	if h := acceptance.Hits[1]; h.Signal != model.SignalFilename || h.Custom {
+		t.Errorf("acceptance.Hits[1] = %+v", h)
+	}
+	if len(acceptance.Weights) != 1 || acceptance.Weights[0].Weight != 1.45 || acceptance.Agreement != 0.5 {
+		t.Errorf("acceptance resolution = %+v, agreement %v", acceptance.Weights, acceptance.Agreement)
+	}
+	if len(acceptance.Notes) != 2 || !strings.Contains(acceptance.Notes[0], "extension rules skipped") ||
+		!strings.Contains(acceptance.Notes[1], "header rules skipped") {
+		t.Errorf("acceptance notes = %q, want the skipped extension and header stages", acceptance.Notes)
+	}
+
+	client := explanations[1]
+	if len(client.Hits) != 2 || client.Hits[0].Signal != model.SignalHeader ||
+		client.Hits[0].Match != "// Code generated by oapi-codegen. DO NOT EDIT." {
+		t.Errorf("client hits = %+v, want two header hits with the matched line", client.Hits)
+	}
+
+	notes := explanations[2]
+	if len(notes.Hits) != 1 || notes.Hits[0].Signal != model.SignalOverride || notes.Hits[0].Rule != `override "**/*.gen.md"` {
+		t.Errorf("notes hits = %+v, want only the override", notes.Hits)
+	}
+	if notes.Neighborhood == nil || notes.Neighborhood.Dir != "/project/api" || notes.Neighborhood.Files != 2 {
+		t.Errorf("notes neighborhood = %+v, want a vote over /project/api", notes.Neighborhood)
+	}
+}
+
+func TestNeighborhoodVote_Explain(t *testing.T) {
+	record := func(role model.Role, confidence float32) *model.FileRecord {
+		return &model.FileRecord{Role: role, Confidence: confidence}
+	}
+
+	tests := []struct {
+		name    string
+		dir     []*model.FileRecord
+		file    *model.FileRecord
+		applied bool
+		reason  string
+	}{
+		{
+			name:   "too few files",
+			dir:    []*model.FileRecord{record(model.RoleTest, 0.9)},
+			file:   record(model.RoleCore, 0.3),
+			reason: "only 2 files in the directory",
+		},
+		{
+			name:   "too few votes",
+			dir:    []*model.FileRecord{record(model.RoleTest, 0.9), record(model.RoleTest, 0.5)},
+			file:   record(model.RoleCore, 0.3),
+			reason: "only 1 confidently classified file",
+		},
+		{
+			name:   "split vote",
+			dir:    []*model.FileRecord{record(model.RoleTest, 0.9), record(model.RoleDocs, 0.9)},
+			file:   record(model.RoleCore, 0.3),
+			reason: "no role has 70% of the votes",
+		},
+		{
+			name:   "confident file",
+			dir:    []*model.FileRecord{record(model.RoleTest, 0.9), record(model.RoleTest, 0.9)},
+			file:   record(model.RoleCore, 0.65),
+			reason: "confidence 0.65 is not below 0.60",
+		},
+		{
+			name:    "moved",
+			dir:     []*model.FileRecord{record(model.RoleTest, 0.9), record(model.RoleTest, 0.9)},
+			file:    record(model.RoleCore, 0.3),
+			applied: true,
+			reason:  "moved from core to test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vote := voteNeighborhood("/project/pkg", append(tt.dir, tt.file))
+			applied, reason := vote.explain(tt.file)
+			if applied != tt.applied || !strings.Contains(reason, tt.reason) {
+				t.Errorf("explain = %v %q, want %v %q", applied, reason, tt.applied, tt.reason)
+			}
+			if applied != vote.reassigns(tt.file) {
+				t.Errorf("explain and reassigns disagree: %v vs %v", applied, vote.reassigns(tt.file))
+			}
+		})
+	}
+}
@@ -83,6 +83,10 @@ var FilenameRules = []FilenameRule{
 	{"_e2e.", "contains", model.RoleTest, model.TestE2E, 0.80},
 	{".integration.", "contains", model.RoleTest, model.TestIntegration, 0.80},
 	{"_integration.", "contains", model.RoleTest, model.TestIntegration, 0.80},
+	{"bench_test.", "contains", model.RoleTest, model.TestBenchmark, 0.75},
+	{"benchmark_test.", "contains", model.RoleTest, model.TestBenchmark, 0.75},
+	{".bench.", "contains", model.RoleTest, model.TestBenchmark, 0.70},
+	{"fuzz_test.", "contains", model.RoleTest, model.TestFuzz, 0.75},
 	{"_fixture.", "contains", model.RoleTest, model.TestFixture, 0.60},
 	{"_mock.", "contains", model.RoleTest, model.TestFixture, 0.55},
 	{"_stub.", "contains", model.RoleTest, model.TestFixture, 0.55},
@@ -180,3 +184,38 @@ var HeaderRules = []HeaderRule{
 	{"// DEPRECATED", model.RoleDeprecated, 0.65},
 	{"@deprecated", model.RoleDeprecated, 0.70},
 }
+
+// MinifiedRule flags minified or bundled files by the shape of their text:
+// hand-written code has short lines separated by whitespace
+type MinifiedRule struct {
+	MaxLineLength int     // a line at least this long...
+	AvgLineLength int     // ...with lines this long on average...
+	MaxWhitespace float32 // ...and at most this share of whitespace
+	Weight        float32
+}
+
+// MinifiedRules contains the shape thresholds, checked in order
+var MinifiedRules = []MinifiedRule{
+	{1000, 200, 0.08, 0.95}, // minified assets (app.min.js)
+	{10000, 0, 1.00, 0.90},  // bundles: no hand-written line is 10KB long
+}
+
+// sourceMapWeight applies to files with a sourceMappingURL trailer, which
+// only compilers and bundlers emit
+const sourceMapWeight = 0.90
+
+// LinguistRule weights a linguist-* attribute from .gitattributes. The
+// attributes are set deliberately, for GitHub's language statistics, so they
+// outweigh any single path or filename rule.
+type LinguistRule struct {
+	Attribute string
+	Role      model.Role
+	Weight    float32
+}
+
+// LinguistRules contains the attribute weights
+var LinguistRules = []LinguistRule{
+	{"linguist-generated", model.RoleGenerated, 0.95},
+	{"linguist-vendored", model.RoleVendor, 0.95},
+	{"linguist-documentation", model.RoleDocs, 0.90},
+}
@@ -0,0 +1,272 @@
+package inference
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// RuleKind names the rule table a custom rule belongs to
+type RuleKind string
+
+const (
+	RuleKindPath      RuleKind = "path"
+	RuleKindFilename  RuleKind = "filename"
+	RuleKindExtension RuleKind = "extension"
+	RuleKindHeader    RuleKind = "header"
+)
+
+// CustomRule adds, reweights or disables a weighted classification rule.
+// Unlike an override it is one signal among others, so a file can still be
+// outvoted by stronger rules or by its neighborhood.
+type CustomRule struct {
+	Kind    RuleKind
+	Match   string // path fragment, filename pattern, extension, or header text
+	Type    string // filename: suffix, prefix or contains (default); header: substring (default) or regex
+	Role    model.Role
+	SubRole model.TestKind
+	Weight  float32
+	Disable bool // remove matching rules (of Role only, if set) instead of adding one
+}
+
+// RuleSet is the weighted rules the engine scores files with: the built-in
+// tables with any custom rules merged in
+type RuleSet struct {
+	path      []rule
+	filename  []rule
+	extension []rule
+	header    []rule
+}
+
+type rule struct {
+	match   string
+	typ     string         // filename match type; "regex" for header regexes
+	re      *regexp.Regexp // compiled header regex
+	role    model.Role
+	subRole model.TestKind
+	weight  float32
+	custom  bool // from aloc.yaml
+}
+
+// DefaultRuleSet returns the built-in rules
+func DefaultRuleSet() *RuleSet {
+	rs := &RuleSet{}
+	for _, r := range PathRules {
+		rs.path = append(rs.path, rule{match: r.Fragment, role: r.Role, weight: r.Weight})
+	}
+	for _, r := range FilenameRules {
+		rs.filename = append(rs.filename, rule{match: strings.ToLower(r.Pattern), typ: r.MatchType, role: r.Role, subRole: r.SubRole, weight: r.Weight})
+	}
+	for _, r := range ExtensionRules {
+		rs.extension = append(rs.extension, rule{match: r.Ext, role: r.Role, weight: r.Weight})
+	}
+	for _, r := range HeaderRules {
+		rs.header = append(rs.header, rule{match: r.Pattern, role: r.Role, weight: r.Weight})
+	}
+	return rs
+}
+
+// NewRuleSet merges custom rules, in order, into the built-in rules. A rule
+// with the same kind, match, type and role as an existing one replaces its
+// weight and sub-role; a disabling rule removes every rule with that match
+// (narrowed by type and role when given); anything else is added.
+func NewRuleSet(custom []CustomRule) (*RuleSet, error) {
+	rs := DefaultRuleSet()
+	for _, c := range custom {
+		r, err := compileRule(c)
+		if err != nil {
+			return nil, fmt.Errorf("%s rule %q: %w", c.Kind, c.Match, err)
+		}
+		table := rs.table(c.Kind)
+
+		if c.Disable {
+			*table = slices.DeleteFunc(*table, func(existing rule) bool {
+				return existing.match == r.match && (c.Type == "" || existing.typ == r.typ) && (c.Role == "" || existing.role == c.Role)
+			})
+			continue
+		}
+
+		i := slices.IndexFunc(*table, func(existing rule) bool {
+			return existing.match == r.match && existing.typ == r.typ && existing.role == r.role
+		})
+		if i >= 0 {
+			(*table)[i] = r
+		} else {
+			*table = append(*table, r)
+		}
+	}
+	return rs, nil
+}
+
+// compileRule validates a custom rule and normalizes its match the way the
+// built-in rule of the same kind is written
+func compileRule(c CustomRule) (rule, error) {
+	r := rule{match: c.Match, typ: c.Type, role: c.Role, subRole: c.SubRole, weight: c.Weight, custom: true}
+	if r.match == "" {
+		return rule{}, fmt.Errorf("match is required")
+	}
+
+	switch c.Kind {
+	case RuleKindPath:
+		r.match = strings.ToLower(filepath.ToSlash(r.match))
+	case RuleKindFilename:
+		r.match = strings.ToLower(r.match)
+		if r.typ == "" {
+			r.typ = "contains"
+		}
+		if r.typ != "suffix" && r.typ != "prefix" && r.typ != "contains" {
+			return rule{}, fmt.Errorf("invalid type %q (want suffix, prefix or contains)", r.typ)
+		}
+	case RuleKindExtension:
+		r.match = strings.ToLower(r.match)
+		if !strings.HasPrefix(r.match, ".") {
+			r.match = "." + r.match
+		}
+	case RuleKindHeader:
+		switch r.typ {
+		case "", "substring":
+			r.typ = ""
+		case "regex":
+			re, err := regexp.Compile(r.match)
+			if err != nil {
+				return rule{}, err
+			}
+			r.re = re
+		default:
+			return rule{}, fmt.Errorf("invalid type %q (want substring or regex)", r.typ)
+		}
+	default:
+		return rule{}, fmt.Errorf("unknown rule kind (want path, filename, extension or header)")
+	}
+	if c.Kind != RuleKindFilename && c.Kind != RuleKindHeader && r.typ != "" {
+		return rule{}, fmt.Errorf("type is only supported for filename and header rules")
+	}
+
+	if c.Disable {
+		if c.Role != "" && !model.IsRole(c.Role) {
+			return rule{}, fmt.Errorf("unknown role %q", c.Role)
+		}
+		return r, nil
+	}
+	if !model.IsRole(c.Role) {
+		return rule{}, fmt.Errorf("unknown role %q", c.Role)
+	}
+	if c.SubRole != "" && (c.Role != model.RoleTest || !slices.Contains(model.AllTestKinds, c.SubRole)) {
+		return rule{}, fmt.Errorf("invalid sub_role %q (test rules only: unit, integration, e2e, contract, fixture, benchmark or fuzz)", c.SubRole)
+	}
+	if c.Weight <= 0 || c.Weight > 1 {
+		return rule{}, fmt.Errorf("weight %v out of range (0, 1]", c.Weight)
+	}
+	return r, nil
+}
+
+func (rs *RuleSet) table(kind RuleKind) *[]rule {
+	switch kind {
+	case RuleKindPath:
+		return &rs.path
+	case RuleKindFilename:
+		return &rs.filename
+	case RuleKindExtension:
+		return &rs.extension
+	default:
+		return &rs.header
+	}
+}
+
+func (rs *RuleSet) applyPath(path string, score *RoleScore) {
+	lowerPath := strings.ToLower(path)
+	for _, r := range rs.path {
+		if strings.Contains(lowerPath, r.match) {
+			score.addRule(r, model.SignalPath, "")
+		}
+	}
+}
+
+func (rs *RuleSet) applyFilename(path string, score *RoleScore) {
+	filename := strings.ToLower(filepath.Base(path))
+	for _, r := range rs.filename {
+		var matched bool
+		switch r.typ {
+		case "suffix":
+			matched = strings.HasSuffix(filename, r.match)
+		case "prefix":
+			matched = strings.HasPrefix(filename, r.match)
+		case "contains":
+			matched = strings.Contains(filename, r.match)
+		}
+		if matched {
+			score.addRule(r, model.SignalFilename, "")
+		}
+	}
+}
+
+func (rs *RuleSet) applyExtension(path string, score *RoleScore) {
+	// compound extensions like .pb.go match as suffixes of the name
+	base := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(base)
+	for _, r := range rs.extension {
+		if strings.HasSuffix(base, r.match) || ext == r.match {
+			score.addRule(r, model.SignalExtension, "")
+		}
+	}
+}
+
+func (rs *RuleSet) applyHeader(header string, score *RoleScore) {
+	for _, r := range rs.header {
+		var matched bool
+		if r.re != nil {
+			matched = r.re.MatchString(header)
+		} else {
+			matched = strings.Contains(header, r.match)
+		}
+		if matched {
+			score.addRule(r, model.SignalHeader, header)
+		}
+	}
+}
+
+// describe renders a rule the way it is written in aloc.yaml
+func (r rule) describe(signal model.Signal) string {
+	switch signal {
+	case model.SignalPath:
+		return fmt.Sprintf("path contains %q", r.match)
+	case model.SignalFilename:
+		return fmt.Sprintf("filename %s %q", r.typ, r.match)
+	case model.SignalExtension:
+		return fmt.Sprintf("extension %q", r.match)
+	case model.SignalHeader:
+		if r.re != nil {
+			return fmt.Sprintf("header matches /%s/", r.match)
+		}
+		return fmt.Sprintf("header contains %q", r.match)
+	default:
+		return r.match
+	}
+}
+
+// matchedLine returns the header line a header rule matched
+func (r rule) matchedLine(header string) string {
+	var i int
+	if r.re != nil {
+		loc := r.re.FindStringIndex(header)
+		if loc == nil {
+			return ""
+		}
+		i = loc[0]
+	} else {
+		i = strings.Index(header, r.match)
+		if i < 0 {
+			return ""
+		}
+	}
+	start := strings.LastIndexByte(header[:i], '\n') + 1
+	end := strings.IndexByte(header[i:], '\n')
+	if end < 0 {
+		return strings.TrimSpace(header[start:])
+	}
+	return strings.TrimSpace(header[start : i+end])
+}
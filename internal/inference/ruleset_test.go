@@ -0,0 +1,160 @@
+package inference
+
+import (
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestNewRuleSet_Default(t *testing.T) {
+	rs, err := NewRuleSet(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.path) != len(PathRules) || len(rs.filename) != len(FilenameRules) ||
+		len(rs.extension) != len(ExtensionRules) || len(rs.header) != len(HeaderRules) {
+		t.Error("NewRuleSet(nil) should hold exactly the built-in rules")
+	}
+}
+
+func TestNewRuleSet_Merge(t *testing.T) {
+	rs, err := NewRuleSet([]CustomRule{
+		{Kind: RuleKindPath, Match: "/Acceptance/", Role: model.RoleTest, SubRole: model.TestE2E, Weight: 0.70},
+		{Kind: RuleKindPath, Match: "/tools/", Role: model.RoleScripts, Weight: 0.20},
+		{Kind: RuleKindPath, Match: "/bin/", Disable: true},
+		{Kind: RuleKindFilename, Match: "makefile", Disable: true},
+		{Kind: RuleKindExtension, Match: "proto", Role: model.RoleDocs, Weight: 0.10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	find := func(rules []rule, match string) []rule {
+		var found []rule
+		for _, r := range rules {
+			if r.match == match {
+				found = append(found, r)
+			}
+		}
+		return found
+	}
+
+	if got := find(rs.path, "/acceptance/"); len(got) != 1 || got[0].subRole != model.TestE2E {
+		t.Errorf("added path rule = %+v, want one lowercased e2e rule", got)
+	}
+	if got := find(rs.path, "/tools/"); len(got) != 1 || got[0].weight != 0.20 {
+		t.Errorf("reweighted path rule = %+v, want one rule with weight 0.20", got)
+	}
+	if got := find(rs.path, "/bin/"); len(got) != 0 {
+		t.Errorf("disabled path rule still present: %+v", got)
+	}
+	if got := find(rs.filename, "makefile"); len(got) != 0 {
+		t.Errorf("disabled filename rule still present: %+v", got)
+	}
+	if got := find(rs.extension, ".proto"); len(got) != 1 || got[0].weight != 0.10 {
+		t.Errorf("reweighted extension rule = %+v, want one rule with weight 0.10", got)
+	}
+	if len(rs.path) != len(PathRules) {
+		t.Errorf("path rules = %d, want %d (one added, one disabled)", len(rs.path), len(PathRules))
+	}
+}
+
+func TestNewRuleSet_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule CustomRule
+	}{
+		{"no match", CustomRule{Kind: RuleKindPath, Role: model.RoleTest, Weight: 0.5}},
+		{"unknown kind", CustomRule{Kind: "directory", Match: "x", Role: model.RoleTest, Weight: 0.5}},
+		{"unknown role", CustomRule{Kind: RuleKindPath, Match: "/x/", Role: "prod", Weight: 0.5}},
+		{"zero weight", CustomRule{Kind: RuleKindPath, Match: "/x/", Role: model.RoleTest}},
+		{"weight above 1", CustomRule{Kind: RuleKindPath, Match: "/x/", Role: model.RoleTest, Weight: 1.5}},
+		{"sub-role on non-test", CustomRule{Kind: RuleKindPath, Match: "/x/", Role: model.RoleInfra, SubRole: model.TestE2E, Weight: 0.5}},
+		{"unknown sub-role", CustomRule{Kind: RuleKindPath, Match: "/x/", Role: model.RoleTest, SubRole: "smoke", Weight: 0.5}},
+		{"filename type", CustomRule{Kind: RuleKindFilename, Match: "x", Type: "glob", Role: model.RoleTest, Weight: 0.5}},
+		{"type on path", CustomRule{Kind: RuleKindPath, Match: "/x/", Type: "regex", Role: model.RoleTest, Weight: 0.5}},
+		{"bad regex", CustomRule{Kind: RuleKindHeader, Match: "(", Type: "regex", Role: model.RoleTest, Weight: 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRuleSet([]CustomRule{tt.rule}); err == nil {
+				t.Errorf("NewRuleSet(%+v) succeeded, want error", tt.rule)
+			}
+		})
+	}
+}
+
+func TestEngineInfer_CustomRules(t *testing.T) {
+	rules, err := NewRuleSet([]CustomRule{
+		{Kind: RuleKindPath, Match: "/acceptance/", Role: model.RoleTest, SubRole: model.TestE2E, Weight: 0.70},
+		{Kind: RuleKindPath, Match: "/platform/", Role: model.RoleInfra, Weight: 0.65},
+		{Kind: RuleKindHeader, Match: `(?m)^// Code generated .* DO NOT EDIT\.$`, Type: "regex", Role: model.RoleGenerated, Weight: 0.95},
+		{Kind: RuleKindHeader, Match: "Code generated by", Disable: true},
+		{Kind: RuleKindHeader, Match: "DO NOT EDIT", Disable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(Options{Rules: rules, HeaderProbe: true})
+
+	tests := []struct {
+		path    string
+		header  string
+		want    model.Role
+		wantSub model.TestKind
+	}{
+		{"/project/acceptance/checkout.go", "", model.RoleTest, model.TestE2E},
+		{"/project/platform/network.go", "", model.RoleInfra, ""},
+		{"/project/api/client.go", "// Code generated by oapi-codegen. DO NOT EDIT.\npackage api\n", model.RoleGenerated, ""},
+		// the built-in substring rules are disabled; a mention in prose no longer counts
+		{"/project/api/doc.go", "// This package says DO NOT EDIT in a comment.\npackage api\n", model.RoleCore, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			record := engine.Infer(&model.RawFile{Path: tt.path, LOC: 100, LanguageHint: "Go", Header: []byte(tt.header)})
+			if record.Role != tt.want || record.SubRole != tt.wantSub {
+				t.Errorf("Role = %v/%v, want %v/%v", record.Role, record.SubRole, tt.want, tt.wantSub)
+			}
+		})
+	}
+}
+
+func TestEngineInfer_CustomRulesAreWeighted(t *testing.T) {
+	// a weighted hint, unlike an override, can be outvoted by stronger signals
+	rules, err := NewRuleSet([]CustomRule{
+		{Kind: RuleKindPath, Match: "/platform/", Role: model.RoleInfra, Weight: 0.40},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(Options{Rules: rules})
+
+	record := engine.Infer(&model.RawFile{Path: "/project/platform/network_test.go", LOC: 50, LanguageHint: "Go"})
+	if record.Role != model.RoleTest {
+		t.Errorf("Role = %v, want test (filename rule outweighs the path hint)", record.Role)
+	}
+}
+
+func TestEngineInfer_CustomRole(t *testing.T) {
+	if _, err := NewRuleSet([]CustomRule{{Kind: RuleKindPath, Match: "/migrations/", Role: "migrations", Weight: 0.80}}); err == nil {
+		t.Fatal("rule for an undeclared role should be rejected")
+	}
+
+	if err := model.RegisterRoles([]model.RoleDef{{Name: "migrations"}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { model.RegisterRoles(nil) })
+
+	rules, err := NewRuleSet([]CustomRule{{Kind: RuleKindPath, Match: "/migrations/", Role: "migrations", Weight: 0.80}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewEngine(Options{Rules: rules})
+
+	record := engine.Infer(&model.RawFile{Path: "/project/db/migrations/0001_init.sql", LOC: 40, LanguageHint: "SQL"})
+	if record.Role != "migrations" {
+		t.Errorf("Role = %v, want migrations", record.Role)
+	}
+}
@@ -10,6 +10,8 @@ type RoleScore struct {
 	Weights  map[model.Role]float32
 	Signals  map[model.Role][]model.Signal
 	SubRoles map[model.Role]model.TestKind
+
+	trace *Explanation // records every rule that fires, when explaining
 }
 
 func NewRoleScore() *RoleScore {
@@ -42,9 +44,10 @@ func (s *RoleScore) MaxWeight() float32 {
 	return max
 }
 
-type rankedRole struct {
-	Role   model.Role
-	Weight float32
+// RoleWeight is the summed weight of the rules that voted for a role
+type RoleWeight struct {
+	Role   model.Role `json:"role"`
+	Weight float32    `json:"weight"`
 }
 
 func (s *RoleScore) Resolve() (model.Role, model.TestKind, float32, []model.Signal) {
@@ -53,9 +56,9 @@ func (s *RoleScore) Resolve() (model.Role, model.TestKind, float32, []model.Sign
 	}
 
 	// Sort by weight descending
-	ranked := make([]rankedRole, 0, len(s.Weights))
+	ranked := make([]RoleWeight, 0, len(s.Weights))
 	for role, weight := range s.Weights {
-		ranked = append(ranked, rankedRole{role, weight})
+		ranked = append(ranked, RoleWeight{role, weight})
 	}
 	sort.Slice(ranked, func(i, j int) bool {
 		if ranked[i].Weight == ranked[j].Weight {
@@ -69,10 +72,12 @@ func (s *RoleScore) Resolve() (model.Role, model.TestKind, float32, []model.Sign
 	confidence := topWeight
 
 	// Ambiguity penalty
+	var ambiguous bool
 	if len(ranked) > 1 {
 		secondWeight := ranked[1].Weight
 		if topWeight-secondWeight < 0.15 {
 			confidence *= 0.8 // 20% penalty
+			ambiguous = true
 		}
 	}
 
@@ -87,6 +92,12 @@ func (s *RoleScore) Resolve() (model.Role, model.TestKind, float32, []model.Sign
 		confidence = 1.0
 	}
 
+	if s.trace != nil {
+		s.trace.Weights = ranked
+		s.trace.Ambiguous = ambiguous
+		s.trace.Agreement = agreementFactor
+	}
+
 	// Get sub-role for test
 	var subRole model.TestKind
 	if topRole == model.RoleTest {
@@ -116,5 +127,6 @@ func rolePriority(role model.Role) int {
 	if p, ok := priorities[role]; ok {
 		return p
 	}
-	return 100
+	// user-defined roles yield to the built-ins, in their listing order
+	return 100 + role.Order()
 }
@@ -2,20 +2,60 @@ package model
 
 // LineMetrics contains detailed line counts
 type LineMetrics struct {
-	Total    int `json:"total"`    // raw line count
-	Blanks   int `json:"blanks"`   // empty lines
-	Comments int `json:"comments"` // comment-only lines
-	Code     int `json:"code"`     // code lines (LOC)
+	Total      int `json:"total"`      // raw line count
+	Blanks     int `json:"blanks"`     // empty lines
+	Comments   int `json:"comments"`   // comment-only lines (includes docstrings)
+	Docstrings int `json:"docstrings"` // docstring and doc-comment lines (subset of Comments)
+	Code       int `json:"code"`       // code lines (LOC)
+}
+
+// Add accumulates another set of line counts into m
+func (m *LineMetrics) Add(other LineMetrics) {
+	m.Total += other.Total
+	m.Blanks += other.Blanks
+	m.Comments += other.Comments
+	m.Docstrings += other.Docstrings
+	m.Code += other.Code
+}
+
+// TextShape describes a file's line lengths and whitespace, to spot minified
+// and bundled code
+type TextShape struct {
+	MaxLineLength int  // longest line in bytes
+	Whitespace    int  // space and tab bytes
+	SourceMap     bool // has a sourceMappingURL trailer
+}
+
+// TestMarker is a line setting the kind of a test: a build tag, a pytest
+// mark, a benchmark function
+type TestMarker struct {
+	Kind TestKind // "" = no marker
+	Line string
 }
 
 // RawFile is the scanner output before semantic inference
 type RawFile struct {
-	Path         string
-	Bytes        int64
-	LOC          int                    // code lines (for backward compat)
-	Lines        LineMetrics            // detailed line metrics
-	LanguageHint string
-	Embedded     map[string]LineMetrics // code blocks embedded in this file (e.g., Markdown)
+	Path                string
+	Bytes               int64
+	LOC                 int         // code lines (for backward compat)
+	Lines               LineMetrics // detailed line metrics
+	LanguageHint        string
+	LanguageFromContent bool                   // LanguageHint was disambiguated from content
+	Embedded            map[string]LineMetrics // code blocks embedded in this file (e.g., Markdown)
+	Shape               TextShape              // line length profile (plain source files only)
+	TestMarker          TestMarker             // test kind marker anywhere in the file (plain source files only)
+	Header              []byte                 // leading bytes for header probing; nil = read from disk
+	Submodule           string                 // nested repository (submodule or checkout) holding the file
+	Linguist            Linguist               // linguist-* attributes from .gitattributes
+}
+
+// Linguist holds the linguist-* attributes .gitattributes sets for a file,
+// the markers GitHub's language statistics use
+type Linguist struct {
+	Generated     bool
+	Vendored      bool
+	Documentation bool
+	Language      string // language the file is counted as; "" = detected
 }
 
 // FileRecord is a file with semantic classification
@@ -29,4 +69,7 @@ type FileRecord struct {
 	Confidence float32                `json:"confidence"`
 	Signals    []Signal               `json:"signals"`
 	Embedded   map[string]LineMetrics `json:"embedded,omitempty"` // embedded code blocks by language
+	Submodule  string                 `json:"submodule,omitempty"` // nested repository holding the file
+	Owners     []string               `json:"owners,omitempty"`    // owners from CODEOWNERS, each counted in full
+	Module     string                 `json:"module,omitempty"`    // path of the module holding the file
 }
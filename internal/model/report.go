@@ -15,6 +15,9 @@ type Report struct {
 	Git              *GitMetrics       `json:"git,omitempty"`
 	GitHint          *GitHint          `json:"git_hint,omitempty"`
 	Engineer         *EngineerMetrics  `json:"engineer,omitempty"`
+	Teams            []TeamBreakdown   `json:"teams,omitempty"`
+	Modules          []ModuleReport    `json:"modules,omitempty"`
+	Untested         *UntestedSurface  `json:"untested,omitempty"`
 	Files            []*FileRecord     `json:"files,omitempty"`
 }
 
@@ -89,6 +92,8 @@ type Meta struct {
 	Generator        string    `json:"generator"`
 	GeneratorVersion string    `json:"generator_version"`
 	Repo             *RepoInfo `json:"repo,omitempty"`
+	Partial          bool      `json:"partial,omitempty"`        // the run was stopped before every file was counted or analyzed
+	PartialReason    string    `json:"partial_reason,omitempty"` // "timeout" or "interrupted"
 }
 
 // RepoInfo contains repository metadata
@@ -124,6 +129,50 @@ type Ratios struct {
 	DocsToCore      float32 `json:"docs_to_core"`
 	GeneratedToCore float32 `json:"generated_to_core"`
 	ConfigToCore    float32 `json:"config_to_core"`
+	CustomToCore    map[Role]float32 `json:"custom_to_core,omitempty"` // user-defined roles not counted as core
+}
+
+// TeamBreakdown contains the LOC of one CODEOWNERS team by role
+type TeamBreakdown struct {
+	Owner      string       `json:"owner"` // "" for files no rule assigns an owner
+	LOC        int          `json:"loc"`
+	Files      int          `json:"files"`
+	ByRole     map[Role]int `json:"by_role"`
+	TestToCore float32      `json:"test_to_core"` // 0 when the team owns no core code
+	SharedLOC  int          `json:"shared_loc"`   // LOC co-owned with other teams, counted for each
+}
+
+// UntestedSurface is the core code no test file is paired with, by the
+// naming conventions of its ecosystem (foo.go and foo_test.go)
+type UntestedSurface struct {
+	Files       int           `json:"files"`        // core files without a paired test
+	LOC         int           `json:"loc"`          // their LOC
+	CoreFiles   int           `json:"core_files"`   // core files with code
+	CoreLOC     int           `json:"core_loc"`     // their LOC
+	Tests       int           `json:"tests"`        // test files, fixtures excluded
+	PairedTests int           `json:"paired_tests"` // test files paired with a core file
+	Directories []UntestedDir `json:"directories"`  // most untested LOC first
+}
+
+// UntestedDir is the untested core code of one directory
+type UntestedDir struct {
+	Dir     string   `json:"dir"`
+	LOC     int      `json:"loc"`      // untested core LOC
+	CoreLOC int      `json:"core_loc"` // all core LOC of the directory
+	Files   []string `json:"files"`    // untested core files, largest first
+}
+
+// Module is a project boundary declared by a build manifest
+type Module struct {
+	Path string `json:"path"` // directory relative to the root ("." for the root)
+	Name string `json:"name"` // declared name, or the directory name
+	Kind string `json:"kind"` // go, cargo, npm, python, maven or bazel
+}
+
+// ModuleReport is the report of the files of one module
+type ModuleReport struct {
+	Module
+	Report *Report `json:"report"`
 }
 
 // LanguageComp contains language composition data
@@ -134,6 +183,7 @@ type LanguageComp struct {
 	Files            int                     `json:"files"`
 	Code             int                     `json:"code"`
 	Comments         int                     `json:"comments"`
+	Docstrings       int                     `json:"docstrings"`
 	Blanks           int                     `json:"blanks"`
 	Tests            int                     `json:"tests"`
 	Config           int                     `json:"config"`
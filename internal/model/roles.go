@@ -0,0 +1,167 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RoleDef describes a role. The built-in roles have fixed definitions;
+// more can be declared in aloc.yaml and added with RegisterRoles.
+type RoleDef struct {
+	Name        Role
+	DisplayName string        // shown by renderers; the name when empty
+	Color       SemanticColor // ColorPrimary when empty
+	Order       int           // position in role listings; built-ins are 10, 20, ... 100
+	Core        bool          // counted as core code in ratios, effort and ownership
+}
+
+// customRoles holds the roles added by RegisterRoles, sorted by Order
+var customRoles []RoleDef
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// semanticColors are the color tokens a role can use, by short name
+var semanticColors = map[string]SemanticColor{
+	"primary":      ColorPrimary,
+	"safety":       ColorSafety,
+	"operational":  ColorOperational,
+	"knowledge":    ColorKnowledge,
+	"fragility":    ColorFragility,
+	"low_emphasis": ColorLowEmphasis,
+	"external":     ColorExternal,
+	"warning":      ColorWarning,
+}
+
+// ParseSemanticColor accepts a color token with or without its "semantic."
+// prefix, e.g. "fragility" or "semantic.fragility"
+func ParseSemanticColor(s string) (SemanticColor, error) {
+	if c, ok := semanticColors[strings.TrimPrefix(strings.ToLower(s), "semantic.")]; ok {
+		return c, nil
+	}
+	names := make([]string, 0, len(semanticColors))
+	for name := range semanticColors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return "", fmt.Errorf("unknown color %q (want one of %s)", s, strings.Join(names, ", "))
+}
+
+// RegisterRoles adds user-defined roles, replacing any registered before.
+// Roles without an order are listed after the built-ins, in the order given.
+// Colors may be given without their "semantic." prefix.
+// It must be called before classification starts.
+func RegisterRoles(defs []RoleDef) error {
+	var roles []RoleDef
+	for i, def := range defs {
+		switch {
+		case !roleNamePattern.MatchString(string(def.Name)):
+			return fmt.Errorf("invalid role name %q (lowercase letters, digits, - and _)", def.Name)
+		case slices.Contains(AllRoles, def.Name):
+			return fmt.Errorf("role %q is built in", def.Name)
+		case slices.ContainsFunc(roles, func(r RoleDef) bool { return r.Name == def.Name }):
+			return fmt.Errorf("role %q is defined twice", def.Name)
+		}
+		if def.Color == "" {
+			def.Color = ColorPrimary
+		} else {
+			color, err := ParseSemanticColor(string(def.Color))
+			if err != nil {
+				return fmt.Errorf("role %q: %w", def.Name, err)
+			}
+			def.Color = color
+		}
+		if def.Order == 0 {
+			def.Order = 1000 + i
+		}
+		roles = append(roles, def)
+	}
+	slices.SortStableFunc(roles, func(a, b RoleDef) int { return a.Order - b.Order })
+	customRoles = roles
+	return nil
+}
+
+// builtinOrder is a built-in role's position in role listings
+func builtinOrder(r Role) (int, bool) {
+	i := slices.Index(AllRoles, r)
+	return (i + 1) * 10, i >= 0
+}
+
+// LookupRole returns the definition of a built-in or registered role
+func LookupRole(r Role) (RoleDef, bool) {
+	if order, ok := builtinOrder(r); ok {
+		return RoleDef{Name: r, DisplayName: string(r), Color: r.Color(), Order: order, Core: r == RoleCore}, true
+	}
+	for _, def := range customRoles {
+		if def.Name == r {
+			return def, true
+		}
+	}
+	return RoleDef{}, false
+}
+
+// IsRole reports whether r is a built-in or registered role
+func IsRole(r Role) bool {
+	_, ok := LookupRole(r)
+	return ok
+}
+
+// Roles returns the built-in and registered roles in listing order
+func Roles() []Role {
+	roles := make([]Role, 0, len(AllRoles)+len(customRoles))
+	custom := customRoles
+	for _, r := range AllRoles {
+		order, _ := builtinOrder(r)
+		for len(custom) > 0 && custom[0].Order < order {
+			roles = append(roles, custom[0].Name)
+			custom = custom[1:]
+		}
+		roles = append(roles, r)
+	}
+	for _, def := range custom {
+		roles = append(roles, def.Name)
+	}
+	return roles
+}
+
+// IsCustom reports whether r is a registered role rather than a built-in
+func (r Role) IsCustom() bool {
+	if _, builtin := builtinOrder(r); builtin {
+		return false
+	}
+	return IsRole(r)
+}
+
+// CustomRoles returns the registered roles in listing order
+func CustomRoles() []Role {
+	roles := make([]Role, len(customRoles))
+	for i, def := range customRoles {
+		roles[i] = def.Name
+	}
+	return roles
+}
+
+// DisplayName returns the name renderers show for a role
+func (r Role) DisplayName() string {
+	if def, ok := LookupRole(r); ok && def.DisplayName != "" {
+		return def.DisplayName
+	}
+	return string(r)
+}
+
+// CountsAsCore reports whether a role's code counts as core code: core
+// itself and registered roles declared as core
+func (r Role) CountsAsCore() bool {
+	if r == RoleCore {
+		return true
+	}
+	def, ok := LookupRole(r)
+	return ok && def.Core
+}
+
+// Order returns a role's position in role listings
+func (r Role) Order() int {
+	def, _ := LookupRole(r)
+	return def.Order
+}
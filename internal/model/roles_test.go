@@ -0,0 +1,90 @@
+package model
+
+import (
+	"slices"
+	"testing"
+)
+
+// registerRoles registers roles for the duration of a test
+func registerRoles(t *testing.T, defs ...RoleDef) {
+	t.Helper()
+	if err := RegisterRoles(defs); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { RegisterRoles(nil) })
+}
+
+func TestRegisterRoles(t *testing.T) {
+	registerRoles(t,
+		RoleDef{Name: "migrations", DisplayName: "Migrations", Color: "fragility", Order: 55},
+		RoleDef{Name: "proto-contracts", Core: true},
+		RoleDef{Name: "benchmarks", Color: "semantic.safety", Order: 25},
+	)
+
+	want := []Role{
+		RoleCore, RoleTest, "benchmarks", RoleInfra, RoleDocs, RoleConfig, "migrations",
+		RoleGenerated, RoleVendor, RoleScripts, RoleExamples, RoleDeprecated, "proto-contracts",
+	}
+	if got := Roles(); !slices.Equal(got, want) {
+		t.Errorf("Roles() = %v, want %v", got, want)
+	}
+	if got := CustomRoles(); !slices.Equal(got, []Role{"benchmarks", "migrations", "proto-contracts"}) {
+		t.Errorf("CustomRoles() = %v", got)
+	}
+	if !Role("migrations").IsCustom() || RoleCore.IsCustom() || Role("unknown").IsCustom() {
+		t.Error("IsCustom should hold for registered roles only")
+	}
+
+	tests := []struct {
+		role        Role
+		displayName string
+		color       SemanticColor
+		core        bool
+	}{
+		{RoleCore, "core", ColorPrimary, true},
+		{RoleConfig, "config", ColorFragility, false},
+		{"migrations", "Migrations", ColorFragility, false},
+		{"benchmarks", "benchmarks", ColorSafety, false},
+		{"proto-contracts", "proto-contracts", ColorPrimary, true},
+	}
+	for _, tt := range tests {
+		if !IsRole(tt.role) {
+			t.Errorf("IsRole(%q) = false", tt.role)
+		}
+		if got := tt.role.DisplayName(); got != tt.displayName {
+			t.Errorf("%s.DisplayName() = %q, want %q", tt.role, got, tt.displayName)
+		}
+		if got := tt.role.Color(); got != tt.color {
+			t.Errorf("%s.Color() = %v, want %v", tt.role, got, tt.color)
+		}
+		if got := tt.role.CountsAsCore(); got != tt.core {
+			t.Errorf("%s.CountsAsCore() = %v, want %v", tt.role, got, tt.core)
+		}
+	}
+
+	if IsRole("fixtures") {
+		t.Error("IsRole(fixtures) = true for an undeclared role")
+	}
+}
+
+func TestRegisterRoles_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		defs []RoleDef
+	}{
+		{"empty name", []RoleDef{{Name: ""}}},
+		{"uppercase", []RoleDef{{Name: "Migrations"}}},
+		{"built in", []RoleDef{{Name: RoleDocs}}},
+		{"duplicate", []RoleDef{{Name: "fixtures"}, {Name: "fixtures"}}},
+		{"unknown color", []RoleDef{{Name: "fixtures", Color: "purple"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterRoles(tt.defs); err == nil {
+				RegisterRoles(nil)
+				t.Errorf("RegisterRoles(%+v) succeeded, want error", tt.defs)
+			}
+		})
+	}
+}
@@ -39,6 +39,8 @@ const (
 	TestE2E         TestKind = "e2e"
 	TestContract    TestKind = "contract"
 	TestFixture     TestKind = "fixture"
+	TestBenchmark   TestKind = "benchmark"
+	TestFuzz        TestKind = "fuzz"
 )
 
 // AllTestKinds contains all possible test kinds
@@ -48,6 +50,8 @@ var AllTestKinds = []TestKind{
 	TestE2E,
 	TestContract,
 	TestFixture,
+	TestBenchmark,
+	TestFuzz,
 }
 
 // Signal represents the source of classification evidence
@@ -60,6 +64,10 @@ const (
 	SignalNeighborhood Signal = "neighborhood"
 	SignalHeader       Signal = "header"
 	SignalOverride     Signal = "override"
+	SignalContent      Signal = "content"       // language disambiguated from file content
+	SignalMinified     Signal = "minified"      // long lines and little whitespace (minified or bundled)
+	SignalSubmodule    Signal = "submodule"     // file lives in a git submodule or nested checkout
+	SignalAttributes   Signal = "gitattributes" // linguist-* attribute in .gitattributes
 )
 
 // AllSignals contains all possible signals
@@ -70,6 +78,10 @@ var AllSignals = []Signal{
 	SignalNeighborhood,
 	SignalHeader,
 	SignalOverride,
+	SignalContent,
+	SignalMinified,
+	SignalSubmodule,
+	SignalAttributes,
 }
 
 // SemanticColor represents a semantic color token for rendering
@@ -109,9 +121,13 @@ func (r Role) Color() SemanticColor {
 		return ColorKnowledge
 	case RoleDeprecated:
 		return ColorWarning
-	default:
-		return ColorPrimary
 	}
+	for _, def := range customRoles {
+		if def.Name == r {
+			return def.Color
+		}
+	}
+	return ColorPrimary
 }
 
 // String returns the string representation of a role
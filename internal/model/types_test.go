@@ -89,14 +89,14 @@ func TestAllRolesComplete(t *testing.T) {
 }
 
 func TestAllSignalsComplete(t *testing.T) {
-	if len(AllSignals) != 6 {
-		t.Errorf("AllSignals has %d signals, want 6", len(AllSignals))
+	if len(AllSignals) != 10 {
+		t.Errorf("AllSignals has %d signals, want 10", len(AllSignals))
 	}
 }
 
 func TestAllTestKindsComplete(t *testing.T) {
-	if len(AllTestKinds) != 5 {
-		t.Errorf("AllTestKinds has %d kinds, want 5", len(AllTestKinds))
+	if len(AllTestKinds) != 7 {
+		t.Errorf("AllTestKinds has %d kinds, want 7", len(AllTestKinds))
 	}
 }
 
@@ -110,6 +110,8 @@ func TestTestKindString(t *testing.T) {
 		{TestE2E, "e2e"},
 		{TestContract, "contract"},
 		{TestFixture, "fixture"},
+		{TestBenchmark, "benchmark"},
+		{TestFuzz, "fuzz"},
 	}
 
 	for _, tt := range tests {
@@ -130,6 +132,9 @@ func TestSignalString(t *testing.T) {
 		{SignalNeighborhood, "neighborhood"},
 		{SignalHeader, "header"},
 		{SignalOverride, "override"},
+		{SignalContent, "content"},
+		{SignalMinified, "minified"},
+		{SignalSubmodule, "submodule"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,364 @@
+// Package modules finds project boundaries (Go modules, npm and Cargo
+// workspace members, Python projects, Maven modules, Bazel workspaces and packages) from
+// build manifests.
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// Manifests are the file names that declare modules
+var Manifests = []string{"go.mod", "go.work", "Cargo.toml", "package.json", "pyproject.toml", "pom.xml", "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel", "BUILD.bazel", "BUILD"}
+
+// Detect returns the modules the manifests declare, sorted by path.
+// manifests holds their contents by slash-separated root-relative path.
+//
+// Workspace roots (go.work, package.json with "workspaces", a Cargo.toml
+// without [package], a Maven pom with pom packaging) are not modules
+// themselves. Below an npm workspace root, only its members are. Bazel
+// workspaces (MODULE.bazel, WORKSPACE) are modules, and so are their
+// top-level BUILD packages; packages nested in those are not.
+func Detect(manifests map[string][]byte) []model.Module {
+	paths := make([]string, 0, len(manifests))
+	for p := range manifests {
+		paths = append(paths, p)
+	}
+	// shallow first, so workspace roots are known before their members
+	slices.SortFunc(paths, func(a, b string) int {
+		if n := strings.Count(a, "/") - strings.Count(b, "/"); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+
+	byDir := make(map[string]*model.Module)
+	declare := func(dir, kind, name string) {
+		m, ok := byDir[dir]
+		if !ok {
+			m = &model.Module{Path: dir, Kind: kind}
+			byDir[dir] = m
+		} else if rank(kind) < rank(m.Kind) {
+			m.Kind = kind
+			if name != "" {
+				m.Name = name
+			}
+		}
+		if m.Name == "" {
+			m.Name = name
+		}
+	}
+
+	bazelRoots := make(map[string]bool)
+	for _, p := range paths {
+		switch dir, file := path.Split(p); file {
+		case "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel":
+			bazelRoots[path.Clean(dir)] = true
+		}
+	}
+	buildPackages := make(map[string]bool)
+
+	var npmWorkspaces []workspace
+	for _, p := range paths {
+		dir, file := path.Split(p)
+		dir = path.Clean(dir)
+		content := manifests[p]
+
+		switch file {
+		case "go.mod":
+			declare(dir, "go", goModuleName(content))
+		case "go.work":
+			for _, use := range goWorkUses(content) {
+				declare(path.Join(dir, use), "go", "")
+			}
+		case "Cargo.toml":
+			sections := tomlSections(content)
+			if pkg, ok := sections["package"]; ok {
+				declare(dir, "cargo", pkg["name"])
+			}
+		case "package.json":
+			var pkg packageJSON
+			if json.Unmarshal(content, &pkg) != nil {
+				continue
+			}
+			if patterns := pkg.Workspaces.patterns(); len(patterns) > 0 {
+				npmWorkspaces = append(npmWorkspaces, workspace{dir: dir, members: patterns})
+				continue
+			}
+			if ws, ok := enclosingWorkspace(npmWorkspaces, dir); ok && !ws.has(dir) {
+				continue // a fixture or example, not a workspace member
+			}
+			declare(dir, "npm", pkg.Name)
+		case "pyproject.toml":
+			sections := tomlSections(content)
+			name := sections["project"]["name"]
+			if name == "" {
+				name = sections["tool.poetry"]["name"]
+			}
+			declare(dir, "python", name)
+		case "pom.xml":
+			var pom mavenPOM
+			if xml.Unmarshal(content, &pom) != nil || pom.Packaging == "pom" {
+				continue
+			}
+			declare(dir, "maven", pom.ArtifactID)
+		case "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel":
+			declare(dir, "bazel", bazelName(content))
+		case "BUILD", "BUILD.bazel":
+			buildPackages[dir] = true
+			if label, ok := topLevelPackage(dir, bazelRoots, buildPackages); ok {
+				declare(dir, "bazel", label)
+			}
+		}
+	}
+
+	modules := make([]model.Module, 0, len(byDir))
+	for _, m := range byDir {
+		if m.Name == "" {
+			m.Name = path.Base(m.Path)
+		}
+		modules = append(modules, *m)
+	}
+	slices.SortFunc(modules, func(a, b model.Module) int { return strings.Compare(a.Path, b.Path) })
+	return modules
+}
+
+// Assign sets each record's module to the deepest module containing it;
+// records outside every module get none
+func Assign(modules []model.Module, records []*model.FileRecord) {
+	dirs := make(map[string]bool, len(modules))
+	for _, m := range modules {
+		dirs[m.Path] = true
+	}
+	for _, r := range records {
+		r.Module = ""
+		for dir := path.Dir(filepath.ToSlash(r.Path)); ; dir = path.Dir(dir) {
+			if dirs[dir] {
+				r.Module = dir
+				break
+			}
+			if dir == "." || dir == "/" {
+				break
+			}
+		}
+	}
+}
+
+// Reported reports whether modules split the project: there is more than
+// one, or a single one below the root
+func Reported(modules []model.Module) bool {
+	return len(modules) > 1 || (len(modules) == 1 && modules[0].Path != ".")
+}
+
+// rank orders the kinds of a directory with several manifests; the first
+// names the module
+func rank(kind string) int {
+	switch kind {
+	case "go":
+		return 0
+	case "cargo":
+		return 1
+	case "npm":
+		return 2
+	case "python":
+		return 3
+	case "maven":
+		return 4
+	default:
+		return 5 // bazel
+	}
+}
+
+// goModuleName returns the path of a go.mod's module directive
+func goModuleName(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(name), `"`)
+		}
+	}
+	return ""
+}
+
+// bazelNamePattern matches the name of a module() or workspace() call
+var bazelNamePattern = regexp.MustCompile(`(?:module|workspace)\(\s*name\s*=\s*["']([^"']+)["']`)
+
+// bazelName returns the name a MODULE.bazel or WORKSPACE file declares
+func bazelName(content []byte) string {
+	if m := bazelNamePattern.FindSubmatch(content); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// topLevelPackage returns the label of a BUILD package that is no
+// workspace root and not nested in another package of its workspace.
+// Packages are visited shallow first.
+func topLevelPackage(dir string, roots, packages map[string]bool) (string, bool) {
+	if roots[dir] {
+		return "", false
+	}
+	root := "."
+	for parent := dir; parent != "."; {
+		parent = path.Dir(parent)
+		if roots[parent] {
+			root = parent
+			break
+		}
+		if packages[parent] {
+			return "", false
+		}
+	}
+	if root == "." {
+		return "//" + strings.TrimPrefix(dir, "."), true
+	}
+	return "//" + strings.TrimPrefix(dir, root+"/"), true
+}
+
+// goWorkUses returns the directories of a go.work's use directives, in
+// both the single-line and the block form
+func goWorkUses(content []byte) []string {
+	var uses []string
+	inBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			uses = append(uses, strings.Trim(line, `"`))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			uses = append(uses, strings.Trim(strings.TrimSpace(line[4:]), `"`))
+		}
+	}
+	return uses
+}
+
+// tomlSections reads the string keys of a TOML file's tables, enough for
+// the names in Cargo.toml and pyproject.toml. Tables with no string keys
+// are present but empty.
+func tomlSections(content []byte) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	current := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && !strings.HasPrefix(line, "[[") {
+			current = strings.TrimSpace(strings.Trim(line, "[]"))
+			if sections[current] == nil {
+				sections[current] = make(map[string]string)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			sections[current][strings.TrimSpace(key)] = value[1 : len(value)-1]
+		}
+	}
+	return sections
+}
+
+type packageJSON struct {
+	Name       string        `json:"name"`
+	Workspaces npmWorkspaces `json:"workspaces"`
+}
+
+// npmWorkspaces is either a list of globs or, for Yarn, an object with a
+// "packages" list
+type npmWorkspaces struct {
+	list     []string
+	Packages []string `json:"packages"`
+}
+
+func (w *npmWorkspaces) UnmarshalJSON(data []byte) error {
+	if json.Unmarshal(data, &w.list) == nil {
+		return nil
+	}
+	type object npmWorkspaces
+	return json.Unmarshal(data, (*object)(w))
+}
+
+func (w npmWorkspaces) patterns() []string {
+	return append(w.list, w.Packages...)
+}
+
+// workspace is an npm workspace root and its member globs
+type workspace struct {
+	dir     string
+	members []string
+}
+
+// enclosingWorkspace returns the workspace root a directory is below
+func enclosingWorkspace(workspaces []workspace, dir string) (workspace, bool) {
+	for i := len(workspaces) - 1; i >= 0; i-- {
+		ws := workspaces[i]
+		if ws.dir == "." || strings.HasPrefix(dir, ws.dir+"/") {
+			return ws, true
+		}
+	}
+	return workspace{}, false
+}
+
+// has reports whether a directory matches one of the member globs
+func (ws workspace) has(dir string) bool {
+	rel := dir
+	if ws.dir != "." {
+		rel = strings.TrimPrefix(dir, ws.dir+"/")
+	}
+	for _, pattern := range ws.members {
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches glob segments against path segments, where a "**"
+// segment matches zero or more directories
+func matchGlob(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchGlob(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], parts[1:])
+}
+
+// mavenPOM holds the top-level elements of a pom.xml; a parent's
+// artifactId is nested and not picked up
+type mavenPOM struct {
+	ArtifactID string `xml:"artifactId"`
+	Packaging  string `xml:"packaging"`
+}
@@ -0,0 +1,92 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestDetect(t *testing.T) {
+	manifests := map[string][]byte{
+		"go.work":                                        []byte("go 1.22\n\nuse (\n\t./services/api // the public API\n\t./services/billing\n)\nuse ./tools\n"),
+		"services/api/go.mod":                            []byte("module example.com/api\n\ngo 1.22\n"),
+		"services/billing/go.mod":                        []byte("module \"example.com/billing\"\n"),
+		"web/package.json":                               []byte(`{"name": "web", "workspaces": {"packages": ["packages/*"]}}`),
+		"web/packages/ui/package.json":                   []byte(`{"name": "@acme/ui"}`),
+		"web/packages/ui/test/fixtures/app/package.json": []byte(`{"name": "fixture"}`),
+		"Cargo.toml":                                     []byte("[workspace]\nmembers = [\"crates/*\"]\n"),
+		"crates/core/Cargo.toml":                         []byte("[package]\nname = \"acme-core\"\nversion = \"0.1.0\"\n\n[dependencies]\nserde = \"1\"\n"),
+		"ml/pyproject.toml":                              []byte("[tool.poetry]\nname = 'acme-ml'\n"),
+		"java/pom.xml":                                   []byte(`<project><modelVersion>4.0.0</modelVersion><artifactId>parent</artifactId><packaging>pom</packaging></project>`),
+		"java/app/pom.xml":                               []byte(`<project><parent><artifactId>parent</artifactId></parent><artifactId>app</artifactId></project>`),
+		"third_party/zlib/MODULE.bazel":                  []byte("module(name = \"zlib\", version = \"1.3\")\n"),
+		"third_party/zlib/package.json":                  []byte(`{"name": "zlib-js"}`),
+		"broken/package.json":                            []byte(`{`),
+		"bazel/WORKSPACE":                                []byte("workspace(\n    name = 'acme_bazel',\n)\n"),
+		"bazel/BUILD":                                    []byte(""),
+		"bazel/lib/BUILD.bazel":                          []byte("cc_library(name = \"lib\")\n"),
+		"bazel/lib/internal/BUILD":                       []byte(""),
+		"tools/bzl/BUILD":                                []byte(""),
+		"vendor/proto/WORKSPACE.bazel":                   []byte(""),
+	}
+
+	want := []model.Module{
+		{Path: "bazel", Name: "acme_bazel", Kind: "bazel"},
+		{Path: "bazel/lib", Name: "//lib", Kind: "bazel"}, // bazel/lib/internal is nested in it
+		{Path: "crates/core", Name: "acme-core", Kind: "cargo"},
+		{Path: "java/app", Name: "app", Kind: "maven"},
+		{Path: "ml", Name: "acme-ml", Kind: "python"},
+		{Path: "services/api", Name: "example.com/api", Kind: "go"},
+		{Path: "services/billing", Name: "example.com/billing", Kind: "go"},
+		{Path: "third_party/zlib", Name: "zlib-js", Kind: "npm"}, // npm outranks bazel
+		{Path: "tools", Name: "tools", Kind: "go"},               // named after its directory
+		{Path: "tools/bzl", Name: "//tools/bzl", Kind: "bazel"},
+		{Path: "vendor/proto", Name: "proto", Kind: "bazel"},
+		{Path: "web/packages/ui", Name: "@acme/ui", Kind: "npm"},
+	}
+
+	got := Detect(manifests)
+	if len(got) != len(want) {
+		t.Fatalf("Detect = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("module %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	mods := []model.Module{{Path: "."}, {Path: "services/api"}, {Path: "services/api/internal/gen"}}
+	records := []*model.FileRecord{
+		{Path: "main.go"},
+		{Path: "services/api/server.go"},
+		{Path: "services/api/internal/gen/types.go"},
+		{Path: "services/apiv2/server.go"},
+	}
+	Assign(mods, records)
+
+	want := []string{".", "services/api", "services/api/internal/gen", "."}
+	for i, r := range records {
+		if r.Module != want[i] {
+			t.Errorf("%s: Module = %q, want %q", r.Path, r.Module, want[i])
+		}
+	}
+}
+
+func TestReported(t *testing.T) {
+	tests := []struct {
+		modules []model.Module
+		want    bool
+	}{
+		{nil, false},
+		{[]model.Module{{Path: "."}}, false},
+		{[]model.Module{{Path: "app"}}, true},
+		{[]model.Module{{Path: "."}, {Path: "app"}}, true},
+	}
+	for _, tt := range tests {
+		if got := Reported(tt.modules); got != tt.want {
+			t.Errorf("Reported(%+v) = %v, want %v", tt.modules, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package pairing pairs test files with the source files they test, by the
+// naming and layout conventions of each ecosystem.
+package pairing
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/scanner"
+)
+
+// Pair is a test file and a source file it is named after
+type Pair struct {
+	Test   *model.FileRecord
+	Source *model.FileRecord
+}
+
+// Pairs pairs each test with the core files it is named after:
+//
+//	foo_test.go            foo.go                 (Go, C, C++, Elixir, Dart, Ruby)
+//	FooTest.java, TestFoo  Foo.java               (JVM, C#, PHP, Swift)
+//	x.test.ts, x.spec.tsx  x.ts                   (JavaScript, TypeScript)
+//	__tests__/x.ts         x.ts
+//	test_foo.py            foo.py                 (Python)
+//	tests/foo.rs           foo.rs                 (Rust integration tests)
+//
+// A source must be in the test's language family (a TypeScript test can
+// test a Vue component) and in the test's package. Ecosystems with test
+// trees of their own (src/test/java/, __tests__/, Python and Rust tests/)
+// have them mapped onto source trees, and a Python tests/ tree mirrors the
+// packages below the top-level one; elsewhere (Go) the directories match. A test with no
+// source in its package stays unpaired, however many share its name.
+func Pairs(records []*model.FileRecord) []Pair {
+	type key struct{ family, stem string }
+	sources := make(map[key][]*model.FileRecord)
+	for _, r := range records {
+		if IsSurface(r) {
+			k := key{family(r.Language), strings.ToLower(sourceStem(r.Path))}
+			sources[k] = append(sources[k], r)
+		}
+	}
+
+	var pairs []Pair
+	for _, r := range records {
+		if r.Role != model.RoleTest || r.SubRole == model.TestFixture {
+			continue
+		}
+		stem, ok := testStem(r.Path)
+		if !ok {
+			continue
+		}
+		candidates := sources[key{family(r.Language), strings.ToLower(stem)}]
+		best := 0
+		var matched []*model.FileRecord
+		for _, src := range candidates {
+			score := dirMatch(filepath.Dir(r.Path), filepath.Dir(src.Path), family(r.Language))
+			switch {
+			case score == 0:
+				// another package's file of the same name
+			case score > best:
+				best, matched = score, []*model.FileRecord{src}
+			case score == best:
+				matched = append(matched, src)
+			}
+		}
+		for _, src := range matched {
+			pairs = append(pairs, Pair{Test: r, Source: src})
+		}
+	}
+	return pairs
+}
+
+// IsSurface reports whether a file is source code a test could pair with:
+// core (or counted as core) code in a programming language or a component
+// framework, not markup, stylesheets or data
+func IsSurface(r *model.FileRecord) bool {
+	if !r.Role.CountsAsCore() || r.LOC == 0 {
+		return false
+	}
+	return scanner.GetLanguageCategory(r.Language) == scanner.CategoryPrimary || family(r.Language) == "js"
+}
+
+// family groups languages whose tests cover each other's files
+func family(language string) string {
+	switch language {
+	case "JavaScript", "TypeScript", "JSX", "TSX", "Vue", "Svelte", "Astro":
+		return "js"
+	case "Java", "Kotlin", "Scala", "Groovy":
+		return "jvm"
+	case "C", "C Header", "C++", "C++ Header", "Objective-C", "Objective-C++", "CUDA":
+		return "c"
+	case "Python", "Cython":
+		return "python"
+	}
+	return language
+}
+
+func sourceStem(p string) string {
+	base := filepath.Base(p)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// testSuffixes mark a test by the name of what it tests, longest first
+// where one ends another
+var testSuffixes = []string{
+	"_unittest", "_test", "_tests", "_spec", ".test", ".spec", "-test", "-spec",
+	"TestCase", "Tests", "Test", "Spec",
+}
+
+// testDirs hold tests named exactly like what they test
+var testDirs = map[string]bool{"__tests__": true, "__test__": true, "test": true, "tests": true, "spec": true}
+
+// testStem returns the name of the source file a test is named after
+func testStem(p string) (string, bool) {
+	stem := sourceStem(p)
+	for _, suffix := range testSuffixes {
+		if s, ok := strings.CutSuffix(stem, suffix); ok && s != "" {
+			return s, true
+		}
+	}
+	// Maven failsafe: FooIT
+	if s, ok := strings.CutSuffix(stem, "IT"); ok && s != "" && unicode.IsLower(rune(s[len(s)-1])) {
+		return s, true
+	}
+	if s, ok := strings.CutPrefix(stem, "test_"); ok && s != "" {
+		return s, true
+	}
+	if s, ok := strings.CutPrefix(stem, "Test"); ok && s != "" && unicode.IsUpper(rune(s[0])) {
+		return s, true
+	}
+	if testDirs[filepath.Base(filepath.Dir(p))] {
+		return stem, true
+	}
+	return "", false
+}
+
+// layoutDirs are the directory names test and source trees differ by, per
+// language family with separate test trees, ignored when comparing a
+// test's directory with a source's
+var layoutDirs = map[string]map[string]bool{
+	"jvm": {
+		"src": true, "main": true, "test": true, "it": true, "integrationTest": true,
+		"java": true, "kotlin": true, "scala": true, "groovy": true,
+	},
+	"js": {
+		"__tests__": true, "__test__": true, "test": true, "tests": true, "spec": true, "specs": true,
+		"unit": true, "integration": true, "e2e": true, "src": true, "lib": true,
+	},
+	"python": {"tests": true, "test": true, "unit": true, "integration": true, "src": true},
+	"Rust":   {"tests": true, "src": true},
+}
+
+// dirMatch rates how a source directory matches a test directory in a
+// language family: 2 for the same package (after dropping the family's
+// layout directories), 1 for a Python test tree mirroring the package
+// below its top-level directory, 0 for no match
+func dirMatch(testDir, srcDir, family string) int {
+	test := packagePath(testDir, layoutDirs[family])
+	src := packagePath(srcDir, layoutDirs[family])
+
+	switch {
+	case slices.Equal(test, src):
+		return 2
+	case family == "python" && len(src) > 0 && slices.Equal(test, src[1:]) && inTestTree(testDir):
+		return 1
+	}
+	return 0
+}
+
+// inTestTree reports whether a directory is in a test tree (tests/, spec/)
+func inTestTree(dir string) bool {
+	return slices.ContainsFunc(strings.Split(filepath.ToSlash(dir), "/"), func(part string) bool {
+		return testDirs[part]
+	})
+}
+
+// packagePath is a directory without its layout directories
+func packagePath(dir string, layout map[string]bool) []string {
+	var parts []string
+	for _, part := range strings.Split(path.Clean(filepath.ToSlash(dir)), "/") {
+		if part != "." && !layout[part] {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,105 @@
+package pairing
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestPairs(t *testing.T) {
+	core := func(path, language string) *model.FileRecord {
+		return &model.FileRecord{Path: path, Language: language, Role: model.RoleCore, LOC: 10}
+	}
+	test := func(path, language string) *model.FileRecord {
+		return &model.FileRecord{Path: path, Language: language, Role: model.RoleTest, SubRole: model.TestUnit, LOC: 10}
+	}
+
+	records := []*model.FileRecord{
+		core("internal/scanner/walker.go", "Go"),
+		test("internal/scanner/walker_test.go", "Go"),
+		core("src/main/java/com/acme/Order.java", "Java"),
+		test("src/test/java/com/acme/OrderTest.java", "Java"),
+		test("src/test/java/com/acme/OrderIT.java", "Java"),
+		core("web/src/cart.ts", "TypeScript"),
+		core("web/src/Button.vue", "Vue"),
+		test("web/src/__tests__/cart.test.ts", "TypeScript"),
+		test("web/src/Button.spec.ts", "TypeScript"),
+		core("acme/billing.py", "Python"),
+		test("tests/test_billing.py", "Python"),
+		// two config.go files: the test pairs with its own package's
+		core("server/config.go", "Go"),
+		core("client/config.go", "Go"),
+		test("server/config_test.go", "Go"),
+		// a test in a package without the source stays unpaired
+		core("b/config.go", "Go"),
+		test("a/config_test.go", "Go"),
+		// Go tests sit beside their sources; lib/ and app/ are packages
+		core("internal/app/config.go", "Go"),
+		test("internal/lib/config_test.go", "Go"),
+		test("tests/test_ledger.py", "Python"),
+		core("acme/sub/ledger.py", "Python"),
+		// a Python tests/ tree mirrors the packages below the top-level one
+		core("src/acme/sub/report.py", "Python"),
+		test("tests/sub/test_report.py", "Python"),
+		// same stem, other language family
+		core("tools/walker.py", "Python"),
+		{Path: "testdata/walker_test.go", Language: "Go", Role: model.RoleTest, SubRole: model.TestFixture, LOC: 10},
+		test("internal/scanner/helpers_test.go", "Go"),
+	}
+
+	got := make(map[string][]string)
+	for _, p := range Pairs(records) {
+		got[p.Test.Path] = append(got[p.Test.Path], p.Source.Path)
+	}
+
+	want := map[string][]string{
+		"internal/scanner/walker_test.go":       {"internal/scanner/walker.go"},
+		"src/test/java/com/acme/OrderTest.java": {"src/main/java/com/acme/Order.java"},
+		"src/test/java/com/acme/OrderIT.java":   {"src/main/java/com/acme/Order.java"},
+		"web/src/__tests__/cart.test.ts":        {"web/src/cart.ts"},
+		"web/src/Button.spec.ts":                {"web/src/Button.vue"},
+		"tests/test_billing.py":                 {"acme/billing.py"},
+		"server/config_test.go":                 {"server/config.go"},
+		"tests/sub/test_report.py":              {"src/acme/sub/report.py"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("Pairs = %v, want %v", got, want)
+	}
+	for test, sources := range want {
+		if !slices.Equal(got[test], sources) {
+			t.Errorf("Pairs[%q] = %v, want %v", test, got[test], sources)
+		}
+	}
+}
+
+func TestTestStem(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"pkg/foo_test.go", "foo", true},
+		{"src/FooTest.java", "Foo", true},
+		{"src/FooTests.cs", "Foo", true},
+		{"src/FooTestCase.php", "Foo", true},
+		{"src/TestFoo.java", "Foo", true},
+		{"src/FooIT.java", "Foo", true},
+		{"spec/foo_spec.rb", "foo", true},
+		{"src/x.test.tsx", "x", true},
+		{"src/x.spec.js", "x", true},
+		{"tests/test_foo.py", "foo", true},
+		{"tests/integration.rs", "integration", true},
+		{"src/__tests__/x.ts", "x", true},
+		{"src/Testing.java", "", false},
+		{"src/CIT.java", "", false},
+		{"tests/conftest.py", "conftest", true},
+		{"pkg/helpers.go", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := testStem(tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("testStem(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package progress reports what a long-running analysis is doing on a
+// terminal line that is redrawn in place.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const interval = 100 * time.Millisecond
+
+// Reporter redraws a status line until stopped. A nil *Reporter is valid
+// and reports nothing, so callers need not check whether progress is on.
+type Reporter struct {
+	Files   atomic.Int64
+	Bytes   atomic.Int64
+	Commits atomic.Int64 // passed to git as the commits-read counter
+
+	w     io.Writer
+	start time.Time
+	phase atomic.Value // string
+	stop  chan struct{}
+	once  sync.Once
+	done  sync.WaitGroup
+}
+
+// Start begins redrawing the status line on w
+func Start(w io.Writer) *Reporter {
+	r := &Reporter{w: w, start: time.Now(), stop: make(chan struct{})}
+	r.phase.Store("scanning")
+
+	r.done.Add(1)
+	go func() {
+		defer r.done.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintf(r.w, "\r\033[K%s", r.line(time.Since(r.start)))
+			case <-r.stop:
+				fmt.Fprint(r.w, "\r\033[K")
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// Phase sets the step shown at the start of the line
+func (r *Reporter) Phase(name string) {
+	if r != nil {
+		r.phase.Store(name)
+	}
+}
+
+// File records a scanned file of the given size
+func (r *Reporter) File(size int64) {
+	if r != nil {
+		r.Files.Add(1)
+		r.Bytes.Add(size)
+	}
+}
+
+// CommitCounter returns the counter git history parsing increments, or nil
+func (r *Reporter) CommitCounter() *atomic.Int64 {
+	if r == nil {
+		return nil
+	}
+	return &r.Commits
+}
+
+// Stop clears the status line, so output written afterwards starts on a
+// clean line. It may be called more than once.
+func (r *Reporter) Stop() {
+	if r == nil {
+		return
+	}
+	r.once.Do(func() { close(r.stop) })
+	r.done.Wait()
+}
+
+// line formats the status, e.g. "scanning · 12,345 files · 210.4 MB · 3s"
+func (r *Reporter) line(elapsed time.Duration) string {
+	parts := []string{
+		r.phase.Load().(string),
+		formatCount(r.Files.Load()) + " files",
+		formatBytes(r.Bytes.Load()),
+	}
+	if commits := r.Commits.Load(); commits > 0 {
+		parts = append(parts, formatCount(commits)+" commits")
+	}
+	parts = append(parts, elapsed.Truncate(time.Second).String())
+	return strings.Join(parts, " · ")
+}
+
+func formatCount(n int64) string {
+	s := fmt.Sprint(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,65 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReporter_Line(t *testing.T) {
+	r := &Reporter{}
+	r.phase.Store("scanning")
+	r.File(1536)
+	r.File(2 << 20)
+	for range 1234 {
+		r.File(0)
+	}
+
+	if got, want := r.line(3500*time.Millisecond), "scanning · 1,236 files · 2.0 MB · 3s"; got != want {
+		t.Errorf("line = %q, want %q", got, want)
+	}
+
+	r.Phase("git history")
+	r.CommitCounter().Add(42)
+	if got := r.line(time.Minute); !strings.HasPrefix(got, "git history · ") || !strings.Contains(got, " · 42 commits · 1m0s") {
+		t.Errorf("line = %q, want git history phase with 42 commits", got)
+	}
+}
+
+func TestReporter_Nil(t *testing.T) {
+	var r *Reporter
+	r.Phase("scanning")
+	r.File(100)
+	if r.CommitCounter() != nil {
+		t.Error("CommitCounter of a nil Reporter should be nil")
+	}
+	r.Stop()
+}
+
+func TestReporter_StopClearsLine(t *testing.T) {
+	var buf bytes.Buffer
+	r := Start(&buf)
+	r.Stop()
+	r.Stop()
+	if !strings.HasSuffix(buf.String(), "\r\033[K") {
+		t.Errorf("output = %q, want it to end by clearing the line", buf.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{5 << 30, "5.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
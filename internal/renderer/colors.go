@@ -254,6 +254,24 @@ func (t *Theme) ForRole(role model.Role) lipgloss.Style {
 		return t.Deprecated
 	case model.RoleScripts:
 		return t.Core
+	}
+
+	// user-defined roles pick a semantic color token
+	switch role.Color() {
+	case model.ColorSafety:
+		return t.Safety
+	case model.ColorOperational:
+		return t.Operational
+	case model.ColorKnowledge:
+		return t.Knowledge
+	case model.ColorFragility:
+		return t.Fragility
+	case model.ColorLowEmphasis:
+		return t.LowEmphasis
+	case model.ColorExternal:
+		return t.External
+	case model.ColorWarning:
+		return t.Deprecated
 	default:
 		return t.Primary
 	}
@@ -17,7 +17,8 @@ type Options struct {
 	Compact    bool
 	Width      int
 	Pretty     bool
-	NoEmbedded bool // hide embedded code blocks in Markdown
+	NoEmbedded bool   // hide embedded code blocks in Markdown
+	GroupBy    string // primary grouping: "role" (default), "owner" or "module"
 }
 
 func DefaultOptions() Options {
@@ -99,15 +99,15 @@ func findDominantRole(responsibilities []model.Responsibility) string {
 	pct := float64(top.LOC) / float64(total) * 100
 
 	if pct > 90 {
-		return fmt.Sprintf("%s overwhelmingly dominant (%.1f%%)", top.Role, pct)
+		return fmt.Sprintf("%s overwhelmingly dominant (%.1f%%)", top.Role.DisplayName(), pct)
 	}
 	if pct > 70 {
-		return fmt.Sprintf("%s strongly dominant (%.1f%%)", top.Role, pct)
+		return fmt.Sprintf("%s strongly dominant (%.1f%%)", top.Role.DisplayName(), pct)
 	}
 	if pct > 50 {
-		return fmt.Sprintf("%s dominant (%.1f%%)", top.Role, pct)
+		return fmt.Sprintf("%s dominant (%.1f%%)", top.Role.DisplayName(), pct)
 	}
-	return fmt.Sprintf("%s leads (%.1f%%)", top.Role, pct)
+	return fmt.Sprintf("%s leads (%.1f%%)", top.Role.DisplayName(), pct)
 }
 
 func detectWarnings(report *model.Report) []string {
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/inference"
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/renderer"
+)
+
+// RenderExplanation renders the reasoning behind one file's classification
+// for `aloc explain`
+func RenderExplanation(x *inference.Explanation, theme *renderer.Theme) string {
+	var sb strings.Builder
+
+	sb.WriteString(theme.PrimaryBold.Render(x.Path))
+	sb.WriteString("  ")
+	sb.WriteString(theme.Dim.Render(x.Language))
+	sb.WriteString("\n")
+
+	// rules that fired, in the order they were applied
+	sb.WriteString(theme.Dim.Render("  rules"))
+	sb.WriteString("\n")
+	if len(x.Hits) == 0 {
+		sb.WriteString("    none fired; unmatched files default to core\n")
+	}
+	ruleWidth := 0
+	for _, h := range x.Hits {
+		ruleWidth = max(ruleWidth, len(h.Rule))
+	}
+	for _, h := range x.Hits {
+		label := fmt.Sprintf("%-16s", roleLabel(h.Role, h.SubRole))
+		fmt.Fprintf(&sb, "    %-*s  %s  %.2f", ruleWidth, h.Rule, theme.ForRole(h.Role).Render(label), h.Weight)
+		if h.Custom {
+			sb.WriteString(theme.Dim.Render("  aloc.yaml"))
+		}
+		sb.WriteString("\n")
+		if h.Match != "" {
+			sb.WriteString(theme.Dim.Render(fmt.Sprintf("    %-*s  matched %q", ruleWidth, "", h.Match)))
+			sb.WriteString("\n")
+		}
+	}
+	for _, note := range x.Notes {
+		sb.WriteString(theme.Dim.Render("    " + note))
+		sb.WriteString("\n")
+	}
+
+	// resolution: summed weights, penalties and the first-pass result
+	if len(x.Weights) > 0 {
+		sb.WriteString(theme.Dim.Render("  weights"))
+		sb.WriteString("\n")
+		weights := make([]string, len(x.Weights))
+		for i, w := range x.Weights {
+			weights[i] = fmt.Sprintf("%s %.2f", theme.ForRole(w.Role).Render(w.Role.DisplayName()), w.Weight)
+		}
+		sb.WriteString("    " + strings.Join(weights, " · ") + "\n")
+		if x.Ambiguous {
+			sb.WriteString(theme.Dim.Render("    ambiguous: top two roles within 0.15, confidence ×0.80"))
+			sb.WriteString("\n")
+		}
+		var agreeing int
+		for _, h := range x.Hits {
+			if h.Role == x.Weights[0].Role {
+				agreeing++
+			}
+		}
+		signals := "signals"
+		if agreeing == 1 {
+			signals = "signal"
+		}
+		sb.WriteString(theme.Dim.Render(fmt.Sprintf("    agreement: %d %s for %s, confidence ×%.2f", agreeing, signals, x.Weights[0].Role.DisplayName(), x.Agreement)))
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "  %s  %s  confidence %.2f\n", theme.Dim.Render("resolved    "), theme.ForRole(x.Initial.Role).Render(roleLabel(x.Initial.Role, x.Initial.SubRole)), x.Initial.Confidence)
+
+	// neighborhood pass
+	if v := x.Neighborhood; v != nil {
+		roles := make([]model.Role, 0, len(v.Votes))
+		for role := range v.Votes {
+			roles = append(roles, role)
+		}
+		slices.SortFunc(roles, func(a, b model.Role) int {
+			if n := v.Votes[b] - v.Votes[a]; n != 0 {
+				return n
+			}
+			return strings.Compare(string(a), string(b))
+		})
+		votes := make([]string, len(roles))
+		for i, role := range roles {
+			votes[i] = fmt.Sprintf("%s %d", role.DisplayName(), v.Votes[role])
+		}
+		if len(votes) == 0 {
+			votes = []string{"none"}
+		}
+		files := "files"
+		if v.Files == 1 {
+			files = "file"
+		}
+		fmt.Fprintf(&sb, "  %s  %s/: %d %s, confident votes %s\n", theme.Dim.Render("neighborhood"), v.Dir, v.Files, files, strings.Join(votes, ", "))
+		fmt.Fprintf(&sb, "  %s  %s\n", strings.Repeat(" ", 12), theme.Dim.Render(v.Reason))
+	}
+
+	r := x.Result
+	fmt.Fprintf(&sb, "  %s  %s  confidence %.2f\n", theme.PrimaryBold.Render("result      "), theme.ForRole(r.Role).Render(roleLabel(r.Role, r.SubRole)), r.Confidence)
+	return sb.String()
+}
+
+// roleLabel renders a role with its sub-role, e.g. test/e2e
+func roleLabel(role model.Role, subRole model.TestKind) string {
+	if subRole == "" {
+		return role.DisplayName()
+	}
+	return role.DisplayName() + "/" + string(subRole)
+}
@@ -77,18 +77,11 @@ func renderSparklines(gitMetrics *model.GitMetrics, theme *renderer.Theme, termW
 	// compute target sparkline width
 	targetWidth := sparklineWidth(termWidth)
 
-	// render sparklines for each role (in consistent order)
-	roles := []model.Role{model.RoleCore, model.RoleTest, model.RoleInfra}
-	roleLabels := map[model.Role]string{
-		model.RoleCore:  "core",
-		model.RoleTest:  "test",
-		model.RoleInfra: "infra",
-	}
-
-	for _, role := range roles {
+	// render sparklines for each role (in listing order)
+	for _, role := range model.Roles() {
 		if sparkline, ok := gitMetrics.ChurnSeries[role]; ok {
 			// pad label BEFORE styling (ANSI codes break width calculation)
-			paddedLabel := fmt.Sprintf("%-*s", labelWidth, roleLabels[role])
+			paddedLabel := fmt.Sprintf("%-*s", labelWidth, truncate(role.DisplayName(), labelWidth-1))
 
 			// render adaptive sparkline from raw values
 			var glyphs string
@@ -106,29 +106,38 @@ func RenderHealthRatiosWithGauges(ratios model.Ratios, lines model.LineMetrics,
 	b.WriteString(theme.PrimaryBold.Render("Health Ratios") + "\n")
 	b.WriteString(theme.Dim.Render(strings.Repeat("─", 80)) + "\n")
 
-	// Test/Core with gauge
-	testHealth := assessTestRatio(ratios.TestToCore)
-	b.WriteString(renderRatioWithGauge("Test / Core", float64(ratios.TestToCore), 0.5, 0.8, testHealth, theme))
-
-	// Comment/Code ratio with gauge
-	if lines.Code > 0 {
-		commentRatio := float32(lines.Comments) / float32(lines.Code)
-		commentHealth := assessCommentRatio(commentRatio)
-		b.WriteString(renderRatioWithGauge("Comment / Code", float64(commentRatio), 0.15, 0.35, commentHealth, theme))
+	// ratios to core in role listing order; user-defined roles have no
+	// target range
+	for _, role := range model.Roles() {
+		switch role {
+		case model.RoleTest:
+			testHealth := assessTestRatio(ratios.TestToCore)
+			b.WriteString(renderRatioWithGauge("Test / Core", float64(ratios.TestToCore), 0.5, 0.8, testHealth, theme))
+
+			// Comment/Code ratio with gauge
+			if lines.Code > 0 {
+				commentRatio := float32(lines.Comments) / float32(lines.Code)
+				commentHealth := assessCommentRatio(commentRatio)
+				b.WriteString(renderRatioWithGauge("Comment / Code", float64(commentRatio), 0.15, 0.35, commentHealth, theme))
+			}
+		case model.RoleDocs:
+			docsHealth := assessDocsRatio(ratios.DocsToCore)
+			b.WriteString(renderRatioWithGauge("Docs / Core", float64(ratios.DocsToCore), 0.2, 0.5, docsHealth, theme))
+		case model.RoleInfra:
+			// lower is better
+			infraHealth := assessInfraRatio(ratios.InfraToCore)
+			b.WriteString(renderRatioWithGauge("Infra / Core", float64(ratios.InfraToCore), 0.0, 0.1, infraHealth, theme))
+		case model.RoleConfig:
+			// lower is better
+			configHealth := assessConfigRatio(ratios.ConfigToCore)
+			b.WriteString(renderRatioWithGauge("Config / Core", float64(ratios.ConfigToCore), 0.0, 0.05, configHealth, theme))
+		default:
+			if ratio, ok := ratios.CustomToCore[role]; ok {
+				b.WriteString(fmt.Sprintf("  %-14s %5.2f\n", truncate(role.DisplayName(), 7)+" / Core", ratio))
+			}
+		}
 	}
 
-	// Docs/Core with gauge
-	docsHealth := assessDocsRatio(ratios.DocsToCore)
-	b.WriteString(renderRatioWithGauge("Docs / Core", float64(ratios.DocsToCore), 0.2, 0.5, docsHealth, theme))
-
-	// Infra/Core with gauge (lower is better)
-	infraHealth := assessInfraRatio(ratios.InfraToCore)
-	b.WriteString(renderRatioWithGauge("Infra / Core", float64(ratios.InfraToCore), 0.0, 0.1, infraHealth, theme))
-
-	// Config/Core with gauge (lower is better)
-	configHealth := assessConfigRatio(ratios.ConfigToCore)
-	b.WriteString(renderRatioWithGauge("Config / Core", float64(ratios.ConfigToCore), 0.0, 0.05, configHealth, theme))
-
 	return b.String()
 }
 
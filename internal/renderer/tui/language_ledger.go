@@ -140,21 +140,32 @@ func RenderLanguageLedger(languages []model.LanguageComp, theme *renderer.Theme,
 	}
 	b.WriteString(theme.Dim.Render(strings.Repeat("─", 80)) + "\n")
 
+	// Docstrings column is only shown when some language has docstrings
+	showDocs := false
+	for _, lang := range filtered {
+		if lang.Docstrings > 0 {
+			showDocs = true
+		}
+	}
+
 	// PHASE 1: Format all cells (no printing)
-	// Columns: [indent+language, code, comments, blanks, total, tests]
-	const numCols = 6
+	// Columns: [indent+language, code, comments, (docs), blanks, total, tests]
+	numCols := 6
+	if showDocs {
+		numCols = 7
+	}
 	var rows []tableRow
 
 	// Column header row
 	rows = append(rows, tableRow{
-		cells: []tableCell{
+		cells: withDocsCell([]tableCell{
 			{text: ""},
 			{text: "Code"},
 			{text: "Comments"},
 			{text: "Blanks"},
 			{text: "Total"},
 			{text: "(Tests)", style: styleDim},
-		},
+		}, tableCell{text: "(Docs)", style: styleDim}, showDocs),
 	})
 
 	// Collect embedded rows separately to include in width calculation
@@ -180,19 +191,19 @@ func RenderLanguageLedger(languages []model.LanguageComp, theme *renderer.Theme,
 
 		// Language data row
 		rows = append(rows, tableRow{
-			cells: []tableCell{
+			cells: withDocsCell([]tableCell{
 				{text: "  " + lang.Language},
 				{text: formatLOCPlain(lang.Code), style: styleMagnitude(lang.Code)},
 				{text: formatLOCPlain(lang.Comments), style: styleMagnitude(lang.Comments)},
 				{text: formatLOCPlain(lang.Blanks), style: styleDim},
 				{text: formatLOCPlain(lang.LOCTotal), style: styleMagnitude(lang.LOCTotal)},
 				{text: testText + " " + testPct, style: styleDim},
-			},
+			}, tableCell{text: formatLOCPlain(lang.Docstrings), style: styleDim}, showDocs),
 		})
 
 		// Collect embedded language rows
 		if !noEmbedded && len(lang.Embedded) > 0 {
-			embRows := formatEmbeddedRows(lang.Embedded, theme)
+			embRows := formatEmbeddedRows(lang.Embedded, showDocs, theme)
 			embeddedData = append(embeddedData, embeddedRowData{
 				parentIdx: len(rows) - 1,
 				rows:      embRows,
@@ -207,6 +218,11 @@ func RenderLanguageLedger(languages []model.LanguageComp, theme *renderer.Theme,
 
 	// Ensure minimum widths for readability
 	minWidths := []int{22, 6, 8, 6, 6, 11}
+	alignments := []alignColumn{alignLeft, alignRight, alignRight, alignRight, alignRight, alignRight}
+	if showDocs {
+		minWidths = []int{22, 6, 8, 6, 6, 6, 11}
+		alignments = append(alignments, alignRight)
+	}
 	for i, min := range minWidths {
 		if i < len(colWidths) && colWidths[i] < min {
 			colWidths[i] = min
@@ -215,7 +231,7 @@ func RenderLanguageLedger(languages []model.LanguageComp, theme *renderer.Theme,
 
 	// PHASE 3: Render with computed widths
 	spec := tableSpec{
-		alignments: []alignColumn{alignLeft, alignRight, alignRight, alignRight, alignRight, alignRight},
+		alignments: alignments,
 		colWidths:  colWidths,
 	}
 
@@ -225,6 +241,17 @@ func RenderLanguageLedger(languages []model.LanguageComp, theme *renderer.Theme,
 	return b.String()
 }
 
+// withDocsCell inserts the docstrings cell after the comments column when shown
+func withDocsCell(cells []tableCell, docs tableCell, show bool) []tableCell {
+	if !show {
+		return cells
+	}
+	result := make([]tableCell, 0, len(cells)+1)
+	result = append(result, cells[:3]...)
+	result = append(result, docs)
+	return append(result, cells[3:]...)
+}
+
 // formatLOCPlain formats LOC as plain string without ANSI codes
 // Returns consistent width formatting with magnitude suffix
 func formatLOCPlain(n int) string {
@@ -286,7 +313,7 @@ type embeddedEntry struct {
 }
 
 // formatEmbeddedRows formats embedded language data as table rows
-func formatEmbeddedRows(embedded map[string]model.LineMetrics, _ *renderer.Theme) []tableRow {
+func formatEmbeddedRows(embedded map[string]model.LineMetrics, showDocs bool, _ *renderer.Theme) []tableRow {
 	// Sort embedded languages by total lines (descending)
 	entries := make([]embeddedEntry, 0, len(embedded))
 	for lang, metrics := range embedded {
@@ -306,6 +333,7 @@ func formatEmbeddedRows(embedded map[string]model.LineMetrics, _ *renderer.Theme
 		} else {
 			other.Code += entry.Metrics.Code
 			other.Comments += entry.Metrics.Comments
+			other.Docstrings += entry.Metrics.Docstrings
 			other.Blanks += entry.Metrics.Blanks
 			other.Total += total
 		}
@@ -323,28 +351,28 @@ func formatEmbeddedRows(embedded map[string]model.LineMetrics, _ *renderer.Theme
 		}
 
 		rows = append(rows, tableRow{
-			cells: []tableCell{
+			cells: withDocsCell([]tableCell{
 				{text: fmt.Sprintf("  %s %s", prefix, entry.Language), style: styleDim},
 				{text: formatLOCPlain(m.Code), style: styleDim},
 				{text: formatLOCPlain(m.Comments), style: styleDim},
 				{text: formatLOCPlain(m.Blanks), style: styleDim},
 				{text: formatLOCPlain(total), style: styleDim},
 				{text: ""}, // no tests for embedded
-			},
+			}, tableCell{text: formatLOCPlain(m.Docstrings), style: styleDim}, showDocs),
 		})
 	}
 
 	// Format "(other)" if there are rolled-up languages
 	if other.Total > 0 {
 		rows = append(rows, tableRow{
-			cells: []tableCell{
+			cells: withDocsCell([]tableCell{
 				{text: "  └─ (other)", style: styleDim},
 				{text: formatLOCPlain(other.Code), style: styleDim},
 				{text: formatLOCPlain(other.Comments), style: styleDim},
 				{text: formatLOCPlain(other.Blanks), style: styleDim},
 				{text: formatLOCPlain(other.Total), style: styleDim},
 				{text: ""}, // no tests for embedded
-			},
+			}, tableCell{text: formatLOCPlain(other.Docstrings), style: styleDim}, showDocs),
 		})
 	}
 
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -21,13 +22,24 @@ func RenderLanguageMatrix(languages []model.LanguageComp, theme *renderer.Theme)
 		return b.String()
 	}
 
-	// Define columns (roles to show)
-	roles := []model.Role{model.RoleCore, model.RoleTest, model.RoleInfra, model.RoleDocs, model.RoleConfig}
+	// Define columns (roles to show), plus user-defined roles that have
+	// code, in listing order
+	var roles []model.Role
+	for _, role := range model.Roles() {
+		switch role {
+		case model.RoleCore, model.RoleTest, model.RoleInfra, model.RoleDocs, model.RoleConfig:
+			roles = append(roles, role)
+		default:
+			if role.IsCustom() && slices.ContainsFunc(languages, func(lang model.LanguageComp) bool { return lang.Responsibilities[role] > 0 }) {
+				roles = append(roles, role)
+			}
+		}
+	}
 
 	// Header
 	b.WriteString(fmt.Sprintf("%-14s", ""))
 	for _, role := range roles {
-		b.WriteString(theme.ForRole(role).Render(fmt.Sprintf("%-10s", role)))
+		b.WriteString(theme.ForRole(role).Render(fmt.Sprintf("%-10s", truncate(role.DisplayName(), 9))))
 	}
 	b.WriteString("\n")
 
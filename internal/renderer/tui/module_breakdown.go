@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/renderer"
+)
+
+// RenderModuleBreakdown renders each module's LOC, test/core ratio,
+// languages and role mix, largest first, and names the modules without
+// tests. A limit > 0 shows only the largest modules.
+func RenderModuleBreakdown(modules []model.ModuleReport, totalLOC int, limit int, theme *renderer.Theme) string {
+	var b strings.Builder
+
+	b.WriteString(theme.PrimaryBold.Render("Module Breakdown") + theme.Dim.Render(fmt.Sprintf(" (%d modules)", len(modules))) + "\n")
+	b.WriteString(theme.Dim.Render(strings.Repeat("─", 80)) + "\n")
+
+	if totalLOC == 0 || len(modules) == 0 {
+		b.WriteString("No data\n")
+		return b.String()
+	}
+
+	sorted := make([]model.ModuleReport, len(modules))
+	copy(sorted, modules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Report.Summary.LOCTotal > sorted[j].Report.Summary.LOCTotal
+	})
+	shown := sorted
+	if limit > 0 && len(sorted) > limit {
+		shown = sorted[:limit]
+	}
+
+	nameWidth, langWidth := 0, 0
+	for _, m := range shown {
+		nameWidth = max(nameWidth, len(truncate(m.Path, 32)))
+		langWidth = max(langWidth, len(moduleLanguages(m.Report)))
+	}
+
+	b.WriteString(theme.Dim.Render(fmt.Sprintf("  %-*s  %7s  %5s  %5s  %9s  %-*s  %s", nameWidth, "", "LOC", "Share", "Files", "Test/Core", langWidth, "Languages", "Roles")) + "\n")
+
+	for _, m := range shown {
+		report := m.Report
+		byRole := make(map[model.Role]int, len(report.Responsibilities))
+		for _, r := range report.Responsibilities {
+			byRole[r.Role] = r.LOC
+		}
+
+		loc := fmt.Sprintf("%7s", formatLOCPlain(report.Summary.LOCTotal))
+		b.WriteString(fmt.Sprintf("  %-*s  %s  %4.0f%%  %5d  %s  %s  %s\n",
+			nameWidth, truncate(m.Path, 32),
+			styleMagnitude(report.Summary.LOCTotal)(loc, theme),
+			float64(report.Summary.LOCTotal)/float64(totalLOC)*100,
+			report.Summary.Files,
+			renderTestToCore(report.Ratios.TestToCore, coreLOC(report) > 0, theme),
+			theme.Dim.Render(fmt.Sprintf("%-*s", langWidth, moduleLanguages(report))),
+			renderRoleMix(byRole, report.Summary.LOCTotal, theme)))
+	}
+
+	if hidden := len(sorted) - len(shown); hidden > 0 {
+		var loc int
+		for _, m := range sorted[len(shown):] {
+			loc += m.Report.Summary.LOCTotal
+		}
+		b.WriteString(theme.Dim.Render(fmt.Sprintf("  %d more modules · %s LOC (--by module lists all)", hidden, formatLOCPlain(loc))) + "\n")
+	}
+
+	// workspace roots and files outside every module
+	outside := totalLOC
+	var untested []string
+	for _, m := range sorted {
+		outside -= m.Report.Summary.LOCTotal
+		if m.Report.Ratios.TestToCore == 0 && coreLOC(m.Report) > 0 {
+			untested = append(untested, m.Path)
+		}
+	}
+	if outside > 0 {
+		b.WriteString(theme.Dim.Render(fmt.Sprintf("  outside modules · %s LOC", formatLOCPlain(outside))) + "\n")
+	}
+
+	if len(untested) > 0 {
+		b.WriteString(theme.Warning.Render("  ⚠ ") + fmt.Sprintf("no tests in %s", strings.Join(untested, ", ")) + "\n")
+	}
+
+	return b.String()
+}
+
+// coreLOC sums a report's LOC of roles counted as core
+func coreLOC(report *model.Report) int {
+	var loc int
+	for _, r := range report.Responsibilities {
+		if r.Role.CountsAsCore() {
+			loc += r.LOC
+		}
+	}
+	return loc
+}
+
+// moduleLanguages names a module's two largest languages
+func moduleLanguages(report *model.Report) string {
+	languages := make([]model.LanguageComp, len(report.Languages))
+	copy(languages, report.Languages)
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].LOCTotal > languages[j].LOCTotal
+	})
+
+	var names []string
+	for _, lang := range languages[:min(2, len(languages))] {
+		names = append(names, lang.Language)
+	}
+	return strings.Join(names, ", ")
+}
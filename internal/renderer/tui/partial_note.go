@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/renderer"
+)
+
+// RenderPartialNote warns that the report covers only part of the codebase
+func RenderPartialNote(meta model.Meta, theme *renderer.Theme) string {
+	reason := "stopped early"
+	switch meta.PartialReason {
+	case "timeout":
+		reason = "timed out"
+	case "interrupted":
+		reason = "interrupted"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(theme.Warning.Render("Partial results · scan " + reason + " before the whole codebase was analyzed"))
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -16,6 +16,7 @@ type TUIRenderer struct {
 	writer     io.Writer
 	noColor    bool
 	noEmbedded bool
+	groupBy    string
 }
 
 func NewTUIRenderer(opts renderer.Options) *TUIRenderer {
@@ -37,15 +38,31 @@ func NewTUIRenderer(opts renderer.Options) *TUIRenderer {
 		writer:     opts.Writer,
 		noColor:    opts.NoColor,
 		noEmbedded: opts.NoEmbedded,
+		groupBy:    opts.GroupBy,
 	}
 }
 
 func (r *TUIRenderer) Render(report *model.Report) error {
 	var sections []string
 
+	// 0. Partial results note (scan stopped by --timeout or a signal)
+	if report.Meta.Partial {
+		sections = append(sections, RenderPartialNote(report.Meta, r.theme))
+	}
+
 	// 1. Scale (the answer - facts)
 	sections = append(sections, RenderScaleAndEffort(report, r.theme))
 
+	// 1a. Module or Team Breakdown first and in full when grouping by them
+	byModule := r.groupBy == "module" && len(report.Modules) > 0
+	if byModule {
+		sections = append(sections, RenderModuleBreakdown(report.Modules, report.Summary.LOCTotal, 0, r.theme))
+	}
+	byOwner := r.groupBy == "owner" && len(report.Teams) > 0
+	if byOwner {
+		sections = append(sections, RenderTeamBreakdown(report.Teams, report.Summary.LOCTotal, 0, r.theme))
+	}
+
 	// 2. Responsibility Balance (role distribution)
 	sections = append(sections, RenderResponsibilityBalance(report.Responsibilities, report.Summary.LOCTotal, r.theme))
 
@@ -57,6 +74,21 @@ func (r *TUIRenderer) Render(report *model.Report) error {
 	// 4. Health Ratios (interpretive layer - ratios comparing roles)
 	sections = append(sections, RenderHealthRatiosWithGauges(report.Ratios, report.Summary.Lines, r.theme))
 
+	// 4a. Untested Surface (core files no test is named after)
+	if report.Untested != nil {
+		sections = append(sections, RenderUntestedSurface(report.Untested, 8, r.theme))
+	}
+
+	// 4b. Module Breakdown (manifests split the project), largest modules only
+	if !byModule && len(report.Modules) > 0 {
+		sections = append(sections, RenderModuleBreakdown(report.Modules, report.Summary.LOCTotal, 10, r.theme))
+	}
+
+	// 4c. Team Breakdown (CODEOWNERS found), largest teams only
+	if !byOwner && len(report.Teams) > 0 {
+		sections = append(sections, RenderTeamBreakdown(report.Teams, report.Summary.LOCTotal, 8, r.theme))
+	}
+
 	// 5. Git Dynamics (optional, after Health Ratios)
 	if report.Git != nil {
 		sections = append(sections, RenderGitDynamics(report.Git, r.theme, r.width))
@@ -38,7 +38,7 @@ func RenderResponsibilityBalance(responsibilities []model.Responsibility, totalL
 		pct := float64(r.LOC) / float64(totalLOC) * 100
 		if pct >= 1.0 {
 			roleStyle := theme.ForRole(r.Role)
-			parts = append(parts, roleStyle.Render(fmt.Sprintf("%s %.0f%%", r.Role, pct)))
+			parts = append(parts, roleStyle.Render(fmt.Sprintf("%s %.0f%%", r.Role.DisplayName(), pct)))
 		} else {
 			otherPct += pct
 		}
@@ -50,5 +50,25 @@ func RenderResponsibilityBalance(responsibilities []model.Responsibility, totalL
 
 	b.WriteString(strings.Join(parts, theme.Dim.Render(" · ")) + "\n")
 
+	// test kinds, when there is more than one; tests moved to test by
+	// their neighborhood have no kind
+	for _, r := range sorted {
+		if r.Role == model.RoleTest && len(r.Breakdown) > 1 {
+			var kinds []string
+			rest := float32(100)
+			for _, kind := range model.AllTestKinds {
+				share := r.Breakdown[kind] * 100
+				rest -= share
+				if share >= 1 {
+					kinds = append(kinds, fmt.Sprintf("%s %.0f%%", kind, share))
+				}
+			}
+			if rest >= 1 {
+				kinds = append(kinds, fmt.Sprintf("other %.0f%%", rest))
+			}
+			b.WriteString(theme.Dim.Render("  tests: "+strings.Join(kinds, " · ")) + "\n")
+		}
+	}
+
 	return b.String()
 }
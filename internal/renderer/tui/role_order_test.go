@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/renderer"
+)
+
+// registerRoles registers roles for the duration of a test
+func registerRoles(t *testing.T, defs ...model.RoleDef) {
+	t.Helper()
+	if err := model.RegisterRoles(defs); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { model.RegisterRoles(nil) })
+}
+
+// assertBefore checks that first appears in out, before second
+func assertBefore(t *testing.T, out, first, second string) {
+	t.Helper()
+	i, j := strings.Index(out, first), strings.Index(out, second)
+	if i < 0 || j < 0 || i > j {
+		t.Errorf("want %q before %q in:\n%s", first, second, out)
+	}
+}
+
+func TestRenderers_CustomRoleOrder(t *testing.T) {
+	registerRoles(t,
+		model.RoleDef{Name: "contracts", DisplayName: "contracts", Order: 5},
+		model.RoleDef{Name: "bench", DisplayName: "bench"}, // after the built-ins
+	)
+	theme := renderer.NewNoColorTheme()
+
+	ratios := model.Ratios{
+		TestToCore:   0.5,
+		CustomToCore: map[model.Role]float32{"contracts": 0.2, "bench": 0.1},
+	}
+	health := RenderHealthRatiosWithGauges(ratios, model.LineMetrics{Code: 100, Comments: 20}, theme)
+	assertBefore(t, health, "contra… / Core", "Test / Core")
+	assertBefore(t, health, "Config / Core", "bench / Core")
+
+	languages := []model.LanguageComp{{
+		Language:         "Go",
+		LOCTotal:         300,
+		Responsibilities: map[model.Role]int{model.RoleCore: 100, "contracts": 100, "bench": 100},
+	}}
+	matrix := RenderLanguageMatrix(languages, theme)
+	assertBefore(t, matrix, "contracts", "core")
+	assertBefore(t, matrix, "config", "bench")
+}
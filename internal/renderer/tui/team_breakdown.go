@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/renderer"
+)
+
+// RenderTeamBreakdown renders LOC by CODEOWNERS team with each team's role
+// mix and test/core ratio. A limit > 0 shows only the largest teams.
+func RenderTeamBreakdown(teams []model.TeamBreakdown, totalLOC int, limit int, theme *renderer.Theme) string {
+	var b strings.Builder
+
+	b.WriteString(theme.PrimaryBold.Render("Team Breakdown") + theme.Dim.Render(" (CODEOWNERS)") + "\n")
+	b.WriteString(theme.Dim.Render(strings.Repeat("─", 80)) + "\n")
+
+	if totalLOC == 0 || len(teams) == 0 {
+		b.WriteString("No data\n")
+		return b.String()
+	}
+
+	shown := teams
+	if limit > 0 && len(teams) > limit {
+		shown = teams[:limit]
+	}
+
+	nameWidth := len("unowned")
+	for _, t := range shown {
+		nameWidth = max(nameWidth, len(truncate(t.Owner, 28)))
+	}
+
+	b.WriteString(theme.Dim.Render(fmt.Sprintf("  %-*s  %7s  %5s  %5s  %9s  %s", nameWidth, "", "LOC", "Share", "Files", "Test/Core", "Roles")) + "\n")
+	for _, t := range shown {
+		name := fmt.Sprintf("%-*s", nameWidth, truncate(t.Owner, 28))
+		if t.Owner == "" {
+			name = theme.Dim.Render(fmt.Sprintf("%-*s", nameWidth, "unowned"))
+		}
+
+		loc := fmt.Sprintf("%7s", formatLOCPlain(t.LOC))
+		b.WriteString(fmt.Sprintf("  %s  %s  %4.0f%%  %5d  %s  %s\n",
+			name,
+			styleMagnitude(t.LOC)(loc, theme),
+			float64(t.LOC)/float64(totalLOC)*100,
+			t.Files,
+			renderTestToCore(t.TestToCore, hasCore(t.ByRole), theme),
+			renderRoleMix(t.ByRole, t.LOC, theme)))
+	}
+
+	if hidden := len(teams) - len(shown); hidden > 0 {
+		var loc int
+		for _, t := range teams[len(shown):] {
+			loc += t.LOC
+		}
+		b.WriteString(theme.Dim.Render(fmt.Sprintf("  %d more teams · %s LOC (--by owner lists all)", hidden, formatLOCPlain(loc))) + "\n")
+	}
+
+	if slices.ContainsFunc(teams, func(t model.TeamBreakdown) bool { return t.SharedLOC > 0 }) {
+		b.WriteString(theme.Dim.Render("  co-owned files count for each owner, so shares overlap") + "\n")
+	}
+
+	return b.String()
+}
+
+// renderTestToCore renders a test/core ratio with its health symbol in a
+// 9-wide column; without core code there is no ratio
+func renderTestToCore(ratio float32, hasCore bool, theme *renderer.Theme) string {
+	if !hasCore {
+		return theme.Dim.Render(fmt.Sprintf("%9s", "—"))
+	}
+	health := assessTestRatio(ratio)
+	symbolStyle := theme.Dim
+	if health.IsGood {
+		symbolStyle = theme.Success
+	} else if health.IsWarning {
+		symbolStyle = theme.Warning
+	}
+	return fmt.Sprintf("%7.2f %s", ratio, symbolStyle.Render(health.Symbol))
+}
+
+// renderRoleMix renders the roles with at least 5% of the LOC, largest
+// first
+func renderRoleMix(byRole map[model.Role]int, loc int, theme *renderer.Theme) string {
+	if loc == 0 {
+		return ""
+	}
+	roles := make([]model.Role, 0, len(byRole))
+	for role := range byRole {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		if byRole[roles[i]] != byRole[roles[j]] {
+			return byRole[roles[i]] > byRole[roles[j]]
+		}
+		return roles[i] < roles[j]
+	})
+
+	var parts []string
+	for _, role := range roles {
+		pct := float64(byRole[role]) / float64(loc) * 100
+		if pct < 5 {
+			break
+		}
+		parts = append(parts, theme.ForRole(role).Render(fmt.Sprintf("%s %.0f%%", role.DisplayName(), pct)))
+	}
+	return strings.Join(parts, theme.Dim.Render(" · "))
+}
+
+// hasCore reports whether a role breakdown includes core code
+func hasCore(byRole map[model.Role]int) bool {
+	for role, loc := range byRole {
+		if role.CountsAsCore() && loc > 0 {
+			return true
+		}
+	}
+	return false
+}
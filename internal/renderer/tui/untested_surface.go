@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+	"github.com/modern-tooling/aloc/internal/renderer"
+)
+
+// RenderUntestedSurface renders how much core code no test is paired with
+// and the directories holding most of it. A limit > 0 shows only the
+// directories with the most untested LOC.
+func RenderUntestedSurface(surface *model.UntestedSurface, limit int, theme *renderer.Theme) string {
+	var b strings.Builder
+
+	b.WriteString(theme.PrimaryBold.Render("Untested Surface") + theme.Dim.Render(" (core files without a paired test)") + "\n")
+	b.WriteString(theme.Dim.Render(strings.Repeat("─", 80)) + "\n")
+
+	if surface == nil || surface.CoreFiles == 0 {
+		b.WriteString("No data\n")
+		return b.String()
+	}
+
+	pct := float64(surface.LOC) / float64(max(surface.CoreLOC, 1)) * 100
+	style := theme.Success
+	if pct >= 50 {
+		style = theme.Warning
+	}
+	b.WriteString(fmt.Sprintf("  %d of %d core files (%s of %s LOC, %s) have no paired test\n",
+		surface.Files, surface.CoreFiles,
+		formatLOCPlain(surface.LOC), formatLOCPlain(surface.CoreLOC),
+		style.Render(fmt.Sprintf("%.0f%%", pct))))
+	if surface.Tests > 0 {
+		b.WriteString(theme.Dim.Render(fmt.Sprintf("  %d of %d test files pair with a core file", surface.PairedTests, surface.Tests)) + "\n")
+	}
+
+	dirs := surface.Directories
+	if len(dirs) == 0 {
+		return b.String()
+	}
+	shown := dirs
+	if limit > 0 && len(dirs) > limit {
+		shown = dirs[:limit]
+	}
+
+	dirWidth := 0
+	for _, d := range shown {
+		dirWidth = max(dirWidth, len(truncate(d.Dir, 36)))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(theme.Dim.Render(fmt.Sprintf("  %-*s  %7s  %8s  %5s  %s", dirWidth, "", "Untested", "of Core", "Files", "Largest")) + "\n")
+	for _, d := range shown {
+		loc := fmt.Sprintf("%7s", formatLOCPlain(d.LOC))
+		b.WriteString(fmt.Sprintf("  %-*s  %s  %8s  %5d  %s\n",
+			dirWidth, truncate(d.Dir, 36),
+			styleMagnitude(d.LOC)(loc, theme),
+			fmt.Sprintf("%.0f%%", float64(d.LOC)/float64(max(d.CoreLOC, 1))*100),
+			len(d.Files),
+			theme.Dim.Render(untestedFileNames(d.Files, 3))))
+	}
+
+	if hidden := len(dirs) - len(shown); hidden > 0 {
+		var loc int
+		for _, d := range dirs[len(shown):] {
+			loc += d.LOC
+		}
+		b.WriteString(theme.Dim.Render(fmt.Sprintf("  %d more directories · %s LOC (--format json lists all)", hidden, formatLOCPlain(loc))) + "\n")
+	}
+
+	return b.String()
+}
+
+// untestedFileNames names a directory's first n untested files
+func untestedFileNames(files []string, n int) string {
+	var names []string
+	for _, f := range files[:min(n, len(files))] {
+		names = append(names, filepath.Base(f))
+	}
+	if len(files) > n {
+		names = append(names, fmt.Sprintf("+%d", len(files)-n))
+	}
+	return strings.Join(names, ", ")
+}
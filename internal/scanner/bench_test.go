@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The benchmarks run against the fixtures in testdata/; run them with
+//
+//	go test ./internal/scanner -run '^$' -bench . -benchmem
+//
+// or `make bench`. Throughput (MB/s) is reported for every benchmark.
+
+const testdataRoot = "../../testdata"
+
+// testdataCorpus concatenates every fixture of a language, repeated until it
+// is at least size bytes
+func testdataCorpus(b *testing.B, lang string, size int) []byte {
+	b.Helper()
+	var fixtures []byte
+	err := filepath.WalkDir(testdataRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || DetectLanguage(path) != lang {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		fixtures = append(fixtures, data...)
+		return err
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		b.Skipf("no %s fixtures in %s", lang, testdataRoot)
+	}
+	return bytes.Repeat(fixtures, size/len(fixtures)+1)
+}
+
+func BenchmarkScan_Testdata(b *testing.B) {
+	entries, err := os.ReadDir(testdataRoot)
+	if err != nil {
+		b.Skip(err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		root := filepath.Join(testdataRoot, e.Name())
+		b.Run(e.Name(), func(b *testing.B) {
+			var size int64
+			filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if info, err := d.Info(); err == nil && !d.IsDir() {
+					size += info.Size()
+				}
+				return nil
+			})
+			b.SetBytes(size)
+			b.ReportAllocs()
+
+			for b.Loop() {
+				s, err := NewScanner(root, Options{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				results, errs := s.Scan(context.Background())
+				for range results {
+				}
+				for err := range errs {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCountLines(b *testing.B) {
+	for _, lang := range []string{"Go", "Markdown", "HCL", "YAML"} {
+		text := testdataCorpus(b, lang, 1<<20)
+		b.Run(strings.ReplaceAll(lang, " ", "_"), func(b *testing.B) {
+			b.SetBytes(int64(len(text)))
+			b.ReportAllocs()
+			for b.Loop() {
+				countContentAs(text, lang)
+			}
+		})
+	}
+}
+
+// BenchmarkCountFile compares the two paths for large files: streaming
+// through the pooled read buffer and memory mapping
+func BenchmarkCountFile(b *testing.B) {
+	text := testdataCorpus(b, "Go", 16<<20)
+	path := filepath.Join(b.TempDir(), "large.go")
+	if err := os.WriteFile(path, text, 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("mmap", func(b *testing.B) {
+		b.SetBytes(int64(len(text)))
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := countFile(path, "Go", false); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stream", func(b *testing.B) {
+		bufPtr := bufferPool.Get().(*[]byte)
+		defer bufferPool.Put(bufPtr)
+		b.SetBytes(int64(len(text)))
+		b.ReportAllocs()
+		for b.Loop() {
+			f, err := os.Open(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := countReaderAs(f, "Go", bufPtr); err != nil {
+				b.Fatal(err)
+			}
+			f.Close()
+		}
+	})
+}
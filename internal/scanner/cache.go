@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// racyWindow guards against files modified right after they were counted:
+// entries whose mtime is this close to the scan start are not cached (the
+// same "racily clean" rule git applies to its index)
+const racyWindow = 2 * time.Second
+
+// Cache is an on-disk cache of per-file scan results keyed by path, size and
+// modification time, so unchanged files are not re-read on the next run.
+// It is invalidated as a whole when languages.json, user-defined languages,
+// the aloc version or the aloc executable changes.
+type Cache struct {
+	path    string
+	stamp   string
+	started time.Time
+
+	mu      sync.Mutex
+	old     map[string]cacheEntry // loaded from disk
+	entries map[string]cacheEntry // seen during this run; only these are saved
+}
+
+type cacheEntry struct {
+	Size                   int64
+	ModTime                int64 // unix nanoseconds
+	Lines                  model.LineMetrics
+	Language               string
+	LanguageFromContent    bool
+	LanguageFromAttributes bool // set by linguist-language in .gitattributes
+	Embedded               map[string]model.LineMetrics
+	Shape                  model.TextShape
+	TestMarker             model.TestMarker
+}
+
+type cacheFile struct {
+	Stamp   string
+	Entries map[string]cacheEntry
+}
+
+// DefaultCachePath returns the cache file for a scan root, under the user's
+// cache directory ($XDG_CACHE_HOME/aloc on Linux)
+func DefaultCachePath(root string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dir, "aloc", hex.EncodeToString(sum[:8])+".gob"), nil
+}
+
+// OpenCache loads the cache at path. A missing, corrupt or stale cache file
+// yields an empty cache.
+func OpenCache(path, version string) *Cache {
+	c := &Cache{
+		path:    path,
+		stamp:   cacheStamp(version),
+		started: time.Now(),
+		old:     make(map[string]cacheEntry),
+		entries: make(map[string]cacheEntry),
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return c
+	}
+	defer f.Close()
+
+	var cf cacheFile
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(&cf); err == nil && cf.Stamp == c.stamp {
+		c.old = cf.Entries
+	}
+	return c
+}
+
+// cacheStamp identifies the counting rules a cache was built with: the
+// embedded language table, any user-defined languages and the build
+func cacheStamp(version string) string {
+	h := sha256.New()
+	h.Write(languagesJSON)
+	h.Write([]byte(userLanguagesStamp))
+	h.Write([]byte(buildFingerprint()))
+	return version + "/" + hex.EncodeToString(h.Sum(nil))
+}
+
+// buildFingerprint hashes the running executable, so a rebuild with changed
+// counting code doesn't reuse counts even when the version doesn't change
+// (builds without ldflags are all dev-unknown). It is empty when the
+// executable can't be read.
+func buildFingerprint() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup returns the cached result for a file if its size and mtime are unchanged
+func (c *Cache) lookup(relPath string, info os.FileInfo) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.old[relPath]
+	if !ok || e.Size != info.Size() || e.ModTime != info.ModTime().UnixNano() {
+		return cacheEntry{}, false
+	}
+	c.entries[relPath] = e
+	return e, true
+}
+
+// store records a freshly counted file
+func (c *Cache) store(relPath string, info os.FileInfo, e cacheEntry) {
+	if info.ModTime().After(c.started.Add(-racyWindow)) {
+		return
+	}
+	e.Size = info.Size()
+	e.ModTime = info.ModTime().UnixNano()
+
+	c.mu.Lock()
+	c.entries[relPath] = e
+	c.mu.Unlock()
+}
+
+// SavePartial is Save for a scan that was stopped early: files it didn't
+// reach keep their entries instead of being dropped as deleted
+func (c *Cache) SavePartial() error {
+	c.mu.Lock()
+	for path, e := range c.old {
+		if _, ok := c.entries[path]; !ok {
+			c.entries[path] = e
+		}
+	}
+	c.mu.Unlock()
+	return c.Save()
+}
+
+// Save writes the entries seen during this run, dropping deleted files
+func (c *Cache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	// write to a temp file and rename so concurrent runs never see a partial cache
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	c.mu.Lock()
+	w := bufio.NewWriter(tmp)
+	err = gob.NewEncoder(w).Encode(cacheFile{Stamp: c.stamp, Entries: c.entries})
+	c.mu.Unlock()
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func scanWithCache(t *testing.T, root string, cache *Cache) map[string]*model.RawFile {
+	t.Helper()
+	return scanWith(t, root, Options{Cache: cache})
+}
+
+func scanWith(t *testing.T, root string, opts Options) map[string]*model.RawFile {
+	t.Helper()
+	s, err := NewScanner(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, errs := s.Scan(context.Background())
+	files := make(map[string]*model.RawFile)
+	for f := range results {
+		files[filepath.ToSlash(f.Path)] = f
+	}
+	for err := range errs {
+		t.Errorf("scan error: %v", err)
+	}
+	return files
+}
+
+// setMtime backdates a file so it is outside the racy window
+func setMtime(t *testing.T, path string, mtime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache_SkipsUnchangedFiles(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+	mtime := time.Now().Add(-time.Hour)
+
+	writeFiles(t, root, map[string]string{
+		"a.go": "package a\n\nfunc A() {}\n",
+		"b.go": "package b\n",
+	})
+	setMtime(t, filepath.Join(root, "a.go"), mtime)
+	setMtime(t, filepath.Join(root, "b.go"), mtime)
+
+	cache := OpenCache(cachePath, "test")
+	first := scanWithCache(t, root, cache)
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// same size and mtime: served from the cache even though content differs
+	writeFiles(t, root, map[string]string{
+		"a.go": "// a\n\n// b\n// c\n// ddd\n", // same 23 bytes
+		"b.go": "package b\nvar x = 1\n",
+	})
+	setMtime(t, filepath.Join(root, "a.go"), mtime)
+	setMtime(t, filepath.Join(root, "b.go"), mtime)
+
+	second := scanWithCache(t, root, OpenCache(cachePath, "test"))
+	if second["a.go"].Lines != first["a.go"].Lines {
+		t.Errorf("a.go lines = %+v, want cached %+v", second["a.go"].Lines, first["a.go"].Lines)
+	}
+	if second["b.go"].Lines.Total != 2 {
+		t.Errorf("b.go total = %d, want 2 (size changed, recounted)", second["b.go"].Lines.Total)
+	}
+
+	// a different version invalidates the whole cache
+	third := scanWithCache(t, root, OpenCache(cachePath, "other"))
+	if third["a.go"].Lines.Comments != 4 {
+		t.Errorf("a.go comments = %d, want 4 after version change", third["a.go"].Lines.Comments)
+	}
+}
+
+func TestCache_RedisambiguatesNeighbors(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+	mtime := time.Now().Add(-time.Hour)
+
+	writeFiles(t, root, map[string]string{
+		"geo/point.h":   "struct point { int x, y; };\n",
+		"geo/point.cpp": "int main() { return 0; }\n",
+	})
+	setMtime(t, filepath.Join(root, "geo", "point.h"), mtime)
+
+	cache := OpenCache(cachePath, "test")
+	if lang := scanWithCache(t, root, cache)["geo/point.h"].LanguageHint; lang != "C++ Header" {
+		t.Fatalf("point.h beside point.cpp = %q, want C++ Header", lang)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the header itself is unchanged, but its neighbors now say Objective-C
+	if err := os.Remove(filepath.Join(root, "geo", "point.cpp")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{"geo/point.m": "int x;\n"})
+	if lang := scanWithCache(t, root, OpenCache(cachePath, "test"))["geo/point.h"].LanguageHint; lang != "Objective-C" {
+		t.Errorf("point.h beside point.m = %q, want Objective-C", lang)
+	}
+}
+
+func TestCache_SkipsRacyFiles(t *testing.T) {
+	cache := OpenCache(filepath.Join(t.TempDir(), "cache.gob"), "test")
+	path := filepath.Join(t.TempDir(), "new.go")
+	if err := os.WriteFile(path, []byte("package x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.store("new.go", info, cacheEntry{Language: "Go"})
+	if len(cache.entries) != 0 {
+		t.Errorf("cached %d entries for a file modified during the scan, want 0", len(cache.entries))
+	}
+}
+
+func TestOpenCache_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	if err := os.WriteFile(path, []byte("not a cache"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if c := OpenCache(path, "test"); len(c.old) != 0 {
+		t.Errorf("corrupt cache loaded %d entries, want 0", len(c.old))
+	}
+}
+
+func TestBuildFingerprint(t *testing.T) {
+	fp := buildFingerprint()
+	if fp == "" {
+		t.Fatal("buildFingerprint of the test binary is empty")
+	}
+	if again := buildFingerprint(); again != fp {
+		t.Errorf("buildFingerprint = %q, then %q; want it stable", fp, again)
+	}
+}
@@ -0,0 +1,178 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// componentLanguages are markup languages whose files embed <script> and
+// <style> blocks (single-file components and plain HTML)
+var componentLanguages = map[string]bool{
+	"Vue":    true,
+	"Svelte": true,
+	"Astro":  true,
+	"HTML":   true,
+}
+
+// templateLanguage is how component markup outside script and style blocks
+// is counted and reported
+const templateLanguage = "HTML"
+
+var (
+	langAttr = regexp.MustCompile(`(?i)\blang\s*=\s*["']?([\w+-]+)`)
+	typeAttr = regexp.MustCompile(`(?i)\btype\s*=\s*["']?([\w/+.-]+)`)
+)
+
+// componentBlock is an open <script> or <style> block
+type componentBlock struct {
+	lang  string
+	close string // lowercase closing tag, e.g. "</script"
+	lexer *lineLexer
+}
+
+// countComponentWithEmbedded splits a Vue, Svelte, Astro or HTML file into
+// its script, style and template sections, counting each with its own
+// language's syntax. Script and style blocks are reported as embedded code
+// (e.g. TypeScript, SCSS); component markup is reported as HTML.
+func countComponentWithEmbedded(scanner *lineReader, lang string) (model.LineMetrics, map[string]model.LineMetrics, error) {
+
+	var metrics model.LineMetrics
+	embedded := make(map[string]model.LineMetrics)
+	template := newLineLexer(templateLanguage)
+
+	tally := func(lineLang string, kind lineKind) {
+		metrics.Total++
+		addLineKind(&metrics, kind)
+		if lineLang == lang {
+			return // the container's own markup (plain HTML) isn't embedded
+		}
+		m := embedded[lineLang]
+		m.Total++
+		addLineKind(&m, kind)
+		embedded[lineLang] = m
+	}
+
+	var block *componentBlock
+	var openTag strings.Builder // opening tag spanning several lines
+	frontmatter := lang == "Astro"
+	inFrontmatter := false
+
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		line := string(raw)
+		trimmed := strings.TrimSpace(line)
+		lower := strings.ToLower(trimmed)
+
+		switch {
+		case inFrontmatter:
+			// Astro component script between --- fences
+			if trimmed == "---" {
+				inFrontmatter, block = false, nil
+				tally(templateLanguage, lineCode)
+			} else {
+				tally("TypeScript", block.lexer.classify(raw))
+			}
+
+		case frontmatter && trimmed == "---":
+			frontmatter, inFrontmatter = false, true
+			block = &componentBlock{lexer: newLineLexer("TypeScript")}
+			tally(templateLanguage, lineCode)
+
+		case block != nil && block.close != "":
+			if strings.HasPrefix(lower, block.close) {
+				block = nil
+				tally(templateLanguage, lineCode)
+			} else if i := strings.Index(lower, block.close); i >= 0 {
+				// code followed by the closing tag on the same line
+				tally(block.lang, block.lexer.classify([]byte(trimmed[:min(i, len(trimmed))])))
+				block = nil
+			} else {
+				tally(block.lang, block.lexer.classify(raw))
+			}
+
+		case openTag.Len() > 0:
+			openTag.WriteString(" " + trimmed)
+			tally(templateLanguage, lineCode)
+			if strings.Contains(trimmed, ">") {
+				block = openComponentBlock(openTag.String())
+				openTag.Reset()
+			}
+
+		default:
+			if trimmed != "" {
+				frontmatter = false // frontmatter must come first
+			}
+			tag := blockTagName(lower)
+			if tag == "" {
+				tally(templateLanguage, template.classify(raw))
+				continue
+			}
+			tally(templateLanguage, lineCode)
+			end := strings.Index(trimmed, ">")
+			switch {
+			case end < 0:
+				openTag.WriteString(trimmed)
+			case strings.Contains(lower[end:], "</"+tag):
+				// one-line block such as <script src="app.js"></script>
+			default:
+				block = openComponentBlock(trimmed[:end+1])
+			}
+		}
+	}
+
+	if len(embedded) == 0 {
+		return metrics, nil, scanner.Err()
+	}
+	return metrics, embedded, scanner.Err()
+}
+
+// blockTagName returns "script" or "style" if a lowercase line opens such a block
+func blockTagName(lower string) string {
+	for _, tag := range []string{"script", "style"} {
+		rest, ok := strings.CutPrefix(lower, "<"+tag)
+		if ok && (rest == "" || rest[0] == '>' || rest[0] == ' ' || rest[0] == '\t') {
+			return tag
+		}
+	}
+	return ""
+}
+
+// openComponentBlock starts a script or style block from its full opening tag
+func openComponentBlock(tag string) *componentBlock {
+	name := blockTagName(strings.ToLower(tag))
+	lang := blockLanguage(name, tag)
+	return &componentBlock{lang: lang, close: "</" + name, lexer: newLineLexer(lang)}
+}
+
+// blockLanguage resolves a block's language from its lang or type attribute:
+// <script lang="ts">, <style lang="scss">, <script type="application/ld+json">
+func blockLanguage(tagName, tag string) string {
+	lang := "JavaScript"
+	if tagName == "style" {
+		lang = "CSS"
+	}
+
+	if m := langAttr.FindStringSubmatch(tag); m != nil {
+		if name := normalizeCodeBlockLang(m[1]); name != "" {
+			if _, known := GetLanguageConfig(name); known {
+				return name
+			}
+		}
+		return lang // e.g. lang="postcss"
+	}
+
+	if m := typeAttr.FindStringSubmatch(tag); m != nil {
+		mime := strings.ToLower(m[1])
+		switch {
+		case strings.Contains(mime, "typescript"):
+			return "TypeScript"
+		case strings.Contains(mime, "json"):
+			return "JSON"
+		case strings.Contains(mime, "template"), strings.Contains(mime, "html"):
+			return templateLanguage
+		}
+	}
+	return lang
+}
@@ -0,0 +1,111 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestCountComponentWithEmbedded(t *testing.T) {
+	tests := []struct {
+		name         string
+		filename     string
+		content      string
+		wantLines    model.LineMetrics
+		wantEmbedded map[string]model.LineMetrics
+	}{
+		{
+			name:     "vue sfc",
+			filename: "Button.vue",
+			content: `<template>
+  <!-- label -->
+  <button>{{ label }}</button>
+</template>
+
+<script setup lang="ts">
+// props
+const props = defineProps<{ label: string }>()
+</script>
+
+<style scoped lang="scss">
+.btn { color: red; }
+</style>
+`,
+			wantLines: model.LineMetrics{Total: 13, Blanks: 2, Comments: 2, Code: 9},
+			wantEmbedded: map[string]model.LineMetrics{
+				"HTML":       {Total: 10, Blanks: 2, Comments: 1, Code: 7},
+				"TypeScript": {Total: 2, Comments: 1, Code: 1},
+				"Sass":       {Total: 1, Code: 1},
+			},
+		},
+		{
+			name:     "svelte multi-line tag and default languages",
+			filename: "Counter.svelte",
+			content: `<script
+  context="module">
+  let count = 0 // state
+</script>
+<style>
+  /* theme */
+  p { margin: 0 }
+</style>
+<p>{count}</p>
+`,
+			wantLines: model.LineMetrics{Total: 9, Comments: 1, Code: 8},
+			wantEmbedded: map[string]model.LineMetrics{
+				"HTML":       {Total: 6, Code: 6},
+				"JavaScript": {Total: 1, Code: 1},
+				"CSS":        {Total: 2, Comments: 1, Code: 1},
+			},
+		},
+		{
+			name:     "astro frontmatter",
+			filename: "Page.astro",
+			content: `---
+import Layout from './Layout.astro'
+---
+<Layout><h1>Hi</h1></Layout>
+`,
+			wantLines: model.LineMetrics{Total: 4, Code: 4},
+			wantEmbedded: map[string]model.LineMetrics{
+				"HTML":       {Total: 3, Code: 3},
+				"TypeScript": {Total: 1, Code: 1},
+			},
+		},
+		{
+			name:     "html keeps markup in the container",
+			filename: "index.html",
+			content: `<html>
+<script src="app.js"></script>
+<script type="application/ld+json">
+{"@type": "Organization"}
+</script>
+</html>
+`,
+			wantLines: model.LineMetrics{Total: 6, Code: 6},
+			wantEmbedded: map[string]model.LineMetrics{
+				"JSON": {Total: 1, Code: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, embedded, err := countComponentWithEmbedded(newByteLineReader([]byte(tt.content)), DetectLanguage(tt.filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines != tt.wantLines {
+				t.Errorf("lines = %+v, want %+v", lines, tt.wantLines)
+			}
+			if len(embedded) != len(tt.wantEmbedded) {
+				t.Fatalf("embedded = %+v, want %+v", embedded, tt.wantEmbedded)
+			}
+			for lang, want := range tt.wantEmbedded {
+				if embedded[lang] != want {
+					t.Errorf("embedded[%s] = %+v, want %+v", lang, embedded[lang], want)
+				}
+			}
+		})
+	}
+}
@@ -1,16 +1,26 @@
 package scanner
 
 import (
-	"bufio"
+	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
 	"sync"
 
 	"github.com/modern-tooling/aloc/internal/model"
 )
 
+// mmapThreshold is the size from which files are memory-mapped rather than
+// read through a pooled buffer: past a few buffer-fulls, mapping saves the
+// copy into user space and the read syscalls
+const mmapThreshold = 1 << 20
+
+// headerSize is how much of a file is kept for header probing
+const headerSize = 2048
+
 // bufferPool reuses 256KB buffers - the documented sweet spot for SSD I/O
 var bufferPool = sync.Pool{
 	New: func() any {
@@ -38,151 +48,220 @@ func CountLines(path string) (model.LineMetrics, error) {
 	}
 	defer f.Close()
 
-	// Get pooled buffer for binary check
 	bufPtr := bufferPool.Get().(*[]byte)
 	defer bufferPool.Put(bufPtr)
-	buf := *bufPtr
 
-	// Read first chunk and check for binary
-	n, err := f.Read(buf)
-	if err != nil && err != io.EOF {
+	r, _, _, err := openText(f, *bufPtr)
+	if err != nil || r == nil {
 		return model.LineMetrics{}, err
 	}
 
-	// Binary check: look for NUL byte in first 512 bytes
-	checkLen := min(n, 512)
-	for i := 0; i < checkLen; i++ {
-		if buf[i] == 0 {
-			return model.LineMetrics{}, nil // binary file, no metrics
+	lang := detectLangFromPath(path)
+	c, err := measureLines(newLineReader(r, *bufPtr), lang)
+	return c.lines, err
+}
+
+// openText prepares a file for streaming. r yields UTF-8 text, positioned
+// after any BOM, and is nil for binary files. dec is non-nil when the file
+// is transcoded from UTF-16 or Latin-1. buf is scratch space for sniffing;
+// head is the first chunk read into it, valid until buf is reused.
+func openText(f *os.File, buf []byte) (r io.Reader, dec *textDecoder, head []byte, err error) {
+	// Read first chunk to detect binary files and the encoding
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, nil, err
+	}
+	head = buf[:n]
+
+	enc, binary := sniffEncoding(head)
+	if binary {
+		return nil, nil, nil, nil // binary file, no metrics
+	}
+
+	// Seek back to the start of the text
+	if _, err := f.Seek(int64(bomLen(head)), io.SeekStart); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if enc.transcoded() {
+		dec = newTextDecoder(f, enc)
+		return dec, dec, head, nil
+	}
+	return f, nil, head, nil
+}
+
+// captureHeader copies the leading text of a file for header probing,
+// decoded like the rest of the file. Twice headerSize bytes are enough to
+// fill it even from UTF-16.
+func captureHeader(head []byte) []byte {
+	text, _ := decodeContent(head[:min(len(head), 2*headerSize)])
+	return bytes.Clone(text[:min(len(text), headerSize)])
+}
+
+// isBinary reports whether content looks binary: a NUL byte in the first 512 bytes
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 512)], 0) >= 0
+}
+
+// CountContent counts the lines of in-memory file content, such as a blob read
+// from git. Markdown, MDX, notebooks and components also return their embedded code.
+func CountContent(path string, content []byte) (model.LineMetrics, map[string]model.LineMetrics) {
+	c := countContentAs(content, detectLangFromPath(path))
+	return c.lines, c.embedded
+}
+
+// fileCounts is the result of counting one file
+type fileCounts struct {
+	lines    model.LineMetrics
+	embedded map[string]model.LineMetrics
+	shape    model.TextShape
+	marker   model.TestMarker
+	header   []byte // leading text, when requested for header probing
+
+	decodeErr *DecodeError // non-fatal problems decoding UTF-16 text
+}
+
+// countContentAs counts in-memory content (a git blob or a memory-mapped
+// file) with lang's syntax. Lines are sliced from content without copying.
+func countContentAs(content []byte, lang string) fileCounts {
+	text, decodeErr := decodeContent(content)
+	if isBinary(text) {
+		return fileCounts{}
+	}
+
+	var c fileCounts
+	if lang == notebookLanguage {
+		c.lines, c.embedded = countNotebook(text)
+	} else {
+		c, _ = countLines(newByteLineReader(text), lang)
+	}
+	c.decodeErr = decodeErr
+	return c
+}
+
+// countReaderAs counts streamed text with lang's syntax
+func countReaderAs(r io.Reader, lang string, bufPtr *[]byte) (fileCounts, error) {
+	if lang == notebookLanguage {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return fileCounts{}, err
 		}
+		var c fileCounts
+		c.lines, c.embedded = countNotebook(content)
+		return c, nil
 	}
+	return countLines(newLineReader(r, *bufPtr), lang)
+}
 
-	// Seek back to start for line counting
-	if _, err := f.Seek(0, io.SeekStart); err != nil {
-		return model.LineMetrics{}, err
+// countLines dispatches to the container counters (Markdown, components) or
+// counts plain source with lang's syntax
+func countLines(lines *lineReader, lang string) (fileCounts, error) {
+	var c fileCounts
+	var err error
+	switch {
+	case lang == "Markdown" || lang == "MDX":
+		c.lines, c.embedded, err = countMarkdownWithEmbedded(lines)
+	case componentLanguages[lang]:
+		c.lines, c.embedded, err = countComponentWithEmbedded(lines, lang)
+	default:
+		c, err = measureLines(lines, lang)
 	}
+	return c, err
+}
 
-	lang := detectLangFromPath(path)
-	return countLinesFromReader(f, lang, bufPtr), nil
+func countLinesFromReader(f io.Reader, lang string, bufPtr *[]byte) model.LineMetrics {
+	c, _ := measureLines(newLineReader(f, *bufPtr), lang)
+	return c.lines
 }
 
-func countLinesFromReader(f *os.File, lang string, bufPtr *[]byte) model.LineMetrics {
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(*bufPtr, 256*1024)
+// measureLines counts lines, records the text's shape (line lengths,
+// whitespace, source map trailer) for minified file detection and finds
+// the marker setting a test's kind
+func measureLines(scanner *lineReader, lang string) (fileCounts, error) {
 
 	var metrics model.LineMetrics
-	inBlockComment := false
-	blockStart, blockEnd := getBlockCommentMarkers(lang)
-	lineComment := getLineCommentMarker(lang)
+	var shape model.TextShape
+	lexer := newLineLexer(lang)
+	markers := newMarkerScan(lang)
 
 	for scanner.Scan() {
+		line := scanner.Bytes()
 		metrics.Total++
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		// Empty line
-		if trimmed == "" {
-			metrics.Blanks++
-			continue
-		}
-
-		// Track if this line contributes to code
-		isCode := false
-		isComment := false
-
-		// Handle block comments
-		if blockStart != "" {
-			if inBlockComment {
-				// Inside block comment
-				if idx := strings.Index(trimmed, blockEnd); idx >= 0 {
-					inBlockComment = false
-					remainder := strings.TrimSpace(trimmed[idx+len(blockEnd):])
-					if remainder != "" && !strings.HasPrefix(remainder, lineComment) {
-						isCode = true
-					} else if remainder != "" {
-						isComment = true
-					} else {
-						isComment = true
-					}
-				} else {
-					isComment = true
-				}
-			} else if strings.Contains(trimmed, blockStart) {
-				// Check for block comment start
-				startIdx := strings.Index(trimmed, blockStart)
-				beforeComment := strings.TrimSpace(trimmed[:startIdx])
-				endIdx := strings.Index(trimmed[startIdx+len(blockStart):], blockEnd)
-
-				if endIdx >= 0 {
-					// Block comment starts and ends on same line
-					afterComment := strings.TrimSpace(trimmed[startIdx+len(blockStart)+endIdx+len(blockEnd):])
-					if beforeComment != "" || afterComment != "" {
-						isCode = true
-					} else {
-						isComment = true
-					}
-				} else {
-					// Block comment starts but doesn't end
-					inBlockComment = true
-					if beforeComment != "" {
-						isCode = true
-					} else {
-						isComment = true
-					}
-				}
-			} else if lineComment != "" && strings.HasPrefix(trimmed, lineComment) {
-				isComment = true
-			} else {
-				isCode = true
-			}
-		} else {
-			// No block comment support for this language
-			if lineComment != "" && strings.HasPrefix(trimmed, lineComment) {
-				isComment = true
-			} else {
-				isCode = true
+		shape.MaxLineLength = max(shape.MaxLineLength, len(line))
+		for _, c := range line {
+			if c == ' ' || c == '\t' {
+				shape.Whitespace++
 			}
 		}
-
-		if isCode {
-			metrics.Code++
-		} else if isComment {
-			metrics.Comments++
+		if !shape.SourceMap && isSourceMapComment(line) {
+			shape.SourceMap = true
 		}
+		markers.scan(line)
+		addLineKind(&metrics, lexer.classify(line))
 	}
 
-	return metrics
+	return fileCounts{lines: metrics, shape: shape, marker: markers.marker}, scanner.Err()
+}
+
+// isSourceMapComment matches the trailer compilers and bundlers append:
+// //# sourceMappingURL=app.js.map or /*# sourceMappingURL=app.css.map */
+func isSourceMapComment(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	return bytes.HasPrefix(line, []byte("//# sourceMappingURL=")) ||
+		bytes.HasPrefix(line, []byte("/*# sourceMappingURL=")) ||
+		bytes.HasPrefix(line, []byte("//@ sourceMappingURL="))
+}
+
+// addLineKind tallies a classified line into metrics (Total is counted by the caller)
+func addLineKind(metrics *model.LineMetrics, kind lineKind) {
+	switch kind {
+	case lineBlank:
+		metrics.Blanks++
+	case lineComment:
+		metrics.Comments++
+	case lineDocstring:
+		metrics.Comments++
+		metrics.Docstrings++
+	case lineCode:
+		metrics.Code++
+	}
 }
 
 // countLOCFromReader is kept for backward compatibility
-func countLOCFromReader(f *os.File, lang string, bufPtr *[]byte) int {
+func countLOCFromReader(f io.Reader, lang string, bufPtr *[]byte) int {
 	return countLinesFromReader(f, lang, bufPtr).Code
 }
 
-func getLineCommentMarker(lang string) string {
-	if cfg, ok := GetLanguageConfig(lang); ok {
-		if cfg.Blank {
-			return "" // language doesn't support comments
-		}
-		if len(cfg.LineComment) > 0 {
-			return cfg.LineComment[0]
+// commentSyntax returns every line comment marker and block comment pair
+// configured for a language. Unknown languages fall back to C-style comments.
+func commentSyntax(lang string) ([]string, []commentPair) {
+	cfg, ok := GetLanguageConfig(lang)
+	if !ok {
+		return []string{"//"}, []commentPair{{open: "/*", close: "*/"}}
+	}
+	if cfg.Blank {
+		return nil, nil // language doesn't support comments
+	}
+
+	var lineComments []string
+	for _, m := range cfg.LineComment {
+		if m != "" {
+			lineComments = append(lineComments, m)
 		}
 	}
-	// fallback for unknown languages
-	return "//"
-}
 
-func getBlockCommentMarkers(lang string) (string, string) {
-	if cfg, ok := GetLanguageConfig(lang); ok {
-		if cfg.Blank || len(cfg.MultiLineComments) == 0 {
-			return "", "" // no block comments
+	var blocks []commentPair
+	for _, p := range cfg.MultiLineComments {
+		if len(p) == 2 && p[0] != "" && p[1] != "" {
+			blocks = append(blocks, commentPair{open: p[0], close: p[1], nested: cfg.Nested})
 		}
-		if len(cfg.MultiLineComments[0]) == 2 {
-			return cfg.MultiLineComments[0][0], cfg.MultiLineComments[0][1]
+	}
+	for _, p := range cfg.NestedComments {
+		if len(p) == 2 && p[0] != "" && p[1] != "" {
+			blocks = append(blocks, commentPair{open: p[0], close: p[1], nested: true})
 		}
 	}
-	// fallback for unknown languages
-	return "/*", "*/"
+	return lineComments, blocks
 }
 
 func detectLangFromPath(path string) string {
@@ -190,89 +269,110 @@ func detectLangFromPath(path string) string {
 	return extToLanguage(ext)
 }
 
-// CountLinesWithEmbedded counts lines and extracts embedded code blocks (for Markdown/MDX)
+// HasEmbeddedCode reports whether a language's files contain code in other
+// languages that CountLinesWithEmbedded extracts
+func HasEmbeddedCode(lang string) bool {
+	return lang == "Markdown" || lang == "MDX" || lang == notebookLanguage || componentLanguages[lang]
+}
+
+// CountLinesWithEmbedded counts lines and extracts embedded code blocks (for
+// Markdown/MDX), code cells (for Jupyter notebooks) or script and style
+// blocks (for Vue, Svelte, Astro and HTML)
 func CountLinesWithEmbedded(path string) (model.LineMetrics, map[string]model.LineMetrics, error) {
+	c, err := countFile(path, detectLangFromPath(path), false)
+	return c.lines, c.embedded, err
+}
+
+// countFile counts a file with lang's syntax, extracting embedded code for
+// container languages. Binary files yield zero counts. With header set, the
+// file's leading text is kept as well, so header probing needs no second open.
+func countFile(path, lang string, header bool) (fileCounts, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return model.LineMetrics{}, nil, err
+		return fileCounts{}, err
 	}
 	defer f.Close()
 
-	// Get pooled buffer for binary check
+	// large files are mapped instead of copied through the read buffer
+	if info, err := f.Stat(); err == nil && info.Size() >= mmapThreshold {
+		if data, unmap, err := mapFile(f, info.Size()); err == nil {
+			defer unmap()
+			return countMapped(path, data, lang, header)
+		}
+	}
+
 	bufPtr := bufferPool.Get().(*[]byte)
 	defer bufferPool.Put(bufPtr)
-	buf := *bufPtr
 
-	// Read first chunk and check for binary
-	n, err := f.Read(buf)
-	if err != nil && err != io.EOF {
-		return model.LineMetrics{}, nil, err
+	r, dec, head, err := openText(f, *bufPtr)
+	if err != nil || r == nil {
+		return fileCounts{}, err
 	}
-
-	// Binary check: look for NUL byte in first 512 bytes
-	checkLen := min(n, 512)
-	for i := 0; i < checkLen; i++ {
-		if buf[i] == 0 {
-			return model.LineMetrics{}, nil, nil // binary file
-		}
+	var h []byte
+	if header {
+		h = captureHeader(head) // before the buffer is reused for counting
 	}
 
-	// Seek back to start
-	if _, err := f.Seek(0, io.SeekStart); err != nil {
-		return model.LineMetrics{}, nil, err
+	c, err := countReaderAs(r, lang, bufPtr)
+	if dec != nil {
+		c.decodeErr = dec.decodeErr()
 	}
+	c.header = h
+	return c, err
+}
 
-	lang := detectLangFromPath(path)
-	if lang == "Markdown" || lang == "MDX" {
-		return countMarkdownWithEmbedded(f, bufPtr)
+// countMapped counts a memory-mapped file. A file truncated by another
+// process while mapped faults on access; that is reported as an error
+// instead of crashing the scan.
+func countMapped(path string, data []byte, lang string, header bool) (c fileCounts, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			c, err = fileCounts{}, fmt.Errorf("%s: file changed while counting: %v", path, r)
+		}
+	}()
+	c = countContentAs(data, lang)
+	if _, binary := sniffEncoding(data[:min(len(data), 512)]); header && !binary {
+		c.header = captureHeader(data)
 	}
-
-	// Non-Markdown: use regular counting
-	metrics := countLinesFromReader(f, lang, bufPtr)
-	return metrics, nil, nil
+	return c, nil
 }
 
 // countMarkdownWithEmbedded parses Markdown and extracts fenced code blocks
-func countMarkdownWithEmbedded(f *os.File, bufPtr *[]byte) (model.LineMetrics, map[string]model.LineMetrics, error) {
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(*bufPtr, 256*1024)
-
+func countMarkdownWithEmbedded(scanner *lineReader) (model.LineMetrics, map[string]model.LineMetrics, error) {
 	var metrics model.LineMetrics
 	embedded := make(map[string]model.LineMetrics)
 
 	inCodeBlock := false
 	codeBlockLang := ""
-	var codeBlockLines []string
+	var codeBlock model.LineMetrics
+	var codeLexer *lineLexer
 
 	for scanner.Scan() {
 		metrics.Total++
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
+		line := scanner.Bytes()
+		trimmed := bytes.TrimSpace(line)
 
 		// Check for fenced code block start/end
-		if strings.HasPrefix(trimmed, "```") {
+		if bytes.HasPrefix(trimmed, []byte("```")) {
 			if !inCodeBlock {
 				// Starting a code block
 				inCodeBlock = true
-				codeBlockLang = strings.TrimPrefix(trimmed, "```")
-				codeBlockLang = strings.TrimSpace(codeBlockLang)
+				codeBlockLang = string(bytes.TrimSpace(trimmed[3:]))
 				// Normalize language name
 				codeBlockLang = normalizeCodeBlockLang(codeBlockLang)
-				codeBlockLines = nil
+				codeBlock = model.LineMetrics{}
+				codeLexer = newLineLexer(codeBlockLang)
 				metrics.Code++ // the ``` line itself is "code" in Markdown
 			} else {
 				// Ending a code block
 				inCodeBlock = false
 				metrics.Code++ // the closing ``` line
 
-				// Process accumulated code block
-				if codeBlockLang != "" && len(codeBlockLines) > 0 {
-					blockMetrics := countCodeBlockLines(codeBlockLines, codeBlockLang)
+				// Record the block's lines, classified as they were read
+				if codeBlockLang != "" && codeBlock.Total > 0 {
 					existing := embedded[codeBlockLang]
-					existing.Total += blockMetrics.Total
-					existing.Code += blockMetrics.Code
-					existing.Comments += blockMetrics.Comments
-					existing.Blanks += blockMetrics.Blanks
+					existing.Add(codeBlock)
 					embedded[codeBlockLang] = existing
 				}
 				codeBlockLang = ""
@@ -281,12 +381,13 @@ func countMarkdownWithEmbedded(f *os.File, bufPtr *[]byte) (model.LineMetrics, m
 		}
 
 		if inCodeBlock {
-			// Inside code block - accumulate for later processing
-			codeBlockLines = append(codeBlockLines, line)
+			// Inside code block - classify with the block's language
+			codeBlock.Total++
+			addLineKind(&codeBlock, codeLexer.classify(line))
 			metrics.Code++ // code blocks count as code in Markdown
-		} else if trimmed == "" {
+		} else if len(trimmed) == 0 {
 			metrics.Blanks++
-		} else if strings.HasPrefix(trimmed, "<!--") {
+		} else if bytes.HasPrefix(trimmed, []byte("<!--")) {
 			metrics.Comments++
 		} else {
 			metrics.Code++ // prose is "code" in Markdown
@@ -294,9 +395,9 @@ func countMarkdownWithEmbedded(f *os.File, bufPtr *[]byte) (model.LineMetrics, m
 	}
 
 	if len(embedded) == 0 {
-		return metrics, nil, nil
+		return metrics, nil, scanner.Err()
 	}
-	return metrics, embedded, nil
+	return metrics, embedded, scanner.Err()
 }
 
 // normalizeCodeBlockLang converts code fence language hints to canonical names
@@ -330,74 +431,11 @@ func normalizeCodeBlockLang(hint string) string {
 // countCodeBlockLines counts lines within a code block using language-specific rules
 func countCodeBlockLines(lines []string, lang string) model.LineMetrics {
 	var metrics model.LineMetrics
-	lineComment := getLineCommentMarker(lang)
-	blockStart, blockEnd := getBlockCommentMarkers(lang)
-	inBlockComment := false
+	lexer := newLineLexer(lang)
 
 	for _, line := range lines {
 		metrics.Total++
-		trimmed := strings.TrimSpace(line)
-
-		if trimmed == "" {
-			metrics.Blanks++
-			continue
-		}
-
-		isCode := false
-		isComment := false
-
-		// Handle block comments
-		if blockStart != "" {
-			if inBlockComment {
-				if idx := strings.Index(trimmed, blockEnd); idx >= 0 {
-					inBlockComment = false
-					remainder := strings.TrimSpace(trimmed[idx+len(blockEnd):])
-					if remainder != "" && !strings.HasPrefix(remainder, lineComment) {
-						isCode = true
-					} else {
-						isComment = true
-					}
-				} else {
-					isComment = true
-				}
-			} else if strings.Contains(trimmed, blockStart) {
-				startIdx := strings.Index(trimmed, blockStart)
-				beforeComment := strings.TrimSpace(trimmed[:startIdx])
-				endIdx := strings.Index(trimmed[startIdx+len(blockStart):], blockEnd)
-
-				if endIdx >= 0 {
-					afterComment := strings.TrimSpace(trimmed[startIdx+len(blockStart)+endIdx+len(blockEnd):])
-					if beforeComment != "" || afterComment != "" {
-						isCode = true
-					} else {
-						isComment = true
-					}
-				} else {
-					inBlockComment = true
-					if beforeComment != "" {
-						isCode = true
-					} else {
-						isComment = true
-					}
-				}
-			} else if lineComment != "" && strings.HasPrefix(trimmed, lineComment) {
-				isComment = true
-			} else {
-				isCode = true
-			}
-		} else {
-			if lineComment != "" && strings.HasPrefix(trimmed, lineComment) {
-				isComment = true
-			} else {
-				isCode = true
-			}
-		}
-
-		if isCode {
-			metrics.Code++
-		} else if isComment {
-			metrics.Comments++
-		}
+		addLineKind(&metrics, lexer.classify([]byte(line)))
 	}
 
 	return metrics
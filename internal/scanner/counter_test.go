@@ -3,7 +3,10 @@ package scanner
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
 )
 
 func TestCountLOCFromBytes(t *testing.T) {
@@ -158,3 +161,303 @@ func TestCountLOC_TextFileWithNulAfter512(t *testing.T) {
 		t.Errorf("CountLOC for text file with late NUL = %d, want 1", got)
 	}
 }
+
+func TestCountLines_StringLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		code     int
+		comments int
+	}{
+		{
+			name:     "block comment marker in go string",
+			filename: "url.go",
+			content:  "package main\n\nvar url = \"http://x/*\"\n\nfunc main() {}\n",
+			code:     3,
+		},
+		{
+			name:     "line comment marker in go string",
+			filename: "url.go",
+			content:  "package main\n\nvar u = \"// not a comment\"\n// real comment\n",
+			code:     2,
+			comments: 1,
+		},
+		{
+			name:     "escaped quote does not close string",
+			filename: "esc.go",
+			content:  "package main\n\nvar s = \"a \\\" /* b\"\nvar t = 1\n",
+			code:     3,
+		},
+		{
+			name:     "go raw string spans lines",
+			filename: "raw.go",
+			content:  "package main\n\nvar s = `\n// inside raw string\n/* also inside\n`\nvar t = 1\n",
+			code:     6,
+		},
+		{
+			name:     "char literal with quote",
+			filename: "char.c",
+			content:  "char q = '\"';\n/* comment */\nint x = 1;\n",
+			code:     2,
+			comments: 1,
+		},
+		{
+			name:     "javascript glob in template literal",
+			filename: "glob.js",
+			content:  "const g = `src/**/*.js`;\nconst h = 1;\n// done\n",
+			code:     2,
+			comments: 1,
+		},
+		{
+			name:     "python hash inside string",
+			filename: "hash.py",
+			content:  "color = '#fff'\n# comment\n",
+			code:     1,
+			comments: 1,
+		},
+		{
+			name:     "code after closing block comment",
+			filename: "tail.go",
+			content:  "/* start\nend */ var x = 1\n/* a */ // b\n",
+			code:     1,
+			comments: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), tt.filename)
+			os.WriteFile(tmpFile, []byte(tt.content), 0644)
+
+			got, err := CountLines(tmpFile)
+			if err != nil {
+				t.Fatalf("CountLines failed: %v", err)
+			}
+			if got.Code != tt.code {
+				t.Errorf("Code = %d, want %d", got.Code, tt.code)
+			}
+			if got.Comments != tt.comments {
+				t.Errorf("Comments = %d, want %d", got.Comments, tt.comments)
+			}
+		})
+	}
+}
+
+func TestCountLines_AllCommentMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		code     int
+		comments int
+	}{
+		{
+			name:     "php hash and slash comments",
+			filename: "index.php",
+			content:  "<?php\n# hash comment\n// slash comment\necho 1;\n",
+			code:     2,
+			comments: 2,
+		},
+		{
+			name:     "haskell line and block comments",
+			filename: "Main.hs",
+			content:  "-- line\n{- block\n   still block -}\nmain = pure ()\n",
+			code:     1,
+			comments: 3,
+		},
+		{
+			name:     "haskell nested block",
+			filename: "Nested.hs",
+			content:  "{- outer {- inner -}\nstill outer -}\nx = 1\n",
+			code:     1,
+			comments: 2,
+		},
+		{
+			name:     "rust nested block",
+			filename: "lib.rs",
+			content:  "/* a /* b */\nstill comment */\nfn main() {}\n",
+			code:     1,
+			comments: 2,
+		},
+		{
+			name:     "go block does not nest",
+			filename: "main.go",
+			content:  "/* a /* b */\nvar x = 1\n",
+			code:     1,
+			comments: 1,
+		},
+		{
+			name:     "ruby begin end",
+			filename: "app.rb",
+			content:  "=begin\ndocs here\n=end\nputs 1\n",
+			code:     1,
+			comments: 3,
+		},
+		{
+			name:     "lua long comment",
+			filename: "init.lua",
+			content:  "--[[\nlong comment\n]]\nlocal x = 1\n-- short\n",
+			code:     1,
+			comments: 4,
+		},
+		{
+			name:     "json has no comments",
+			filename: "data.json",
+			content:  "{\n  \"url\": \"http://example.com\"\n}\n",
+			code:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), tt.filename)
+			os.WriteFile(tmpFile, []byte(tt.content), 0644)
+
+			got, err := CountLines(tmpFile)
+			if err != nil {
+				t.Fatalf("CountLines failed: %v", err)
+			}
+			if got.Code != tt.code {
+				t.Errorf("Code = %d, want %d", got.Code, tt.code)
+			}
+			if got.Comments != tt.comments {
+				t.Errorf("Comments = %d, want %d", got.Comments, tt.comments)
+			}
+		})
+	}
+}
+
+func TestCountLines_Docstrings(t *testing.T) {
+	tests := []struct {
+		name       string
+		filename   string
+		content    string
+		code       int
+		comments   int
+		docstrings int
+	}{
+		{
+			name:       "python module and function docstrings",
+			filename:   "svc.py",
+			content:    "\"\"\"Module docs.\n\nMore.\n\"\"\"\n\ndef f():\n    \"\"\"Return one.\"\"\"\n    return 1  # trailing\n",
+			code:       2,
+			comments:   4,
+			docstrings: 4,
+		},
+		{
+			name:       "python triple quote after code is a string",
+			filename:   "s.py",
+			content:    "s = \"\"\"\n# not a comment\n\"\"\"\n",
+			code:       3,
+			comments:   0,
+			docstrings: 0,
+		},
+		{
+			name:       "python single quoted docstring",
+			filename:   "q.py",
+			content:    "def f():\n    '''Doc with \"quotes\".'''\n    pass\n",
+			code:       2,
+			comments:   1,
+			docstrings: 1,
+		},
+		{
+			name:       "elixir moduledoc heredoc",
+			filename:   "app.ex",
+			content:    "defmodule App do\n  @moduledoc \"\"\"\n  The app.\n  \"\"\"\n  # note\n  def run, do: :ok\nend\n",
+			code:       3,
+			comments:   4,
+			docstrings: 3,
+		},
+		{
+			name:       "javadoc block",
+			filename:   "App.java",
+			content:    "/**\n * Entry point.\n */\n/* plain */\nclass App {}\n",
+			code:       1,
+			comments:   4,
+			docstrings: 3,
+		},
+		{
+			name:       "empty block is not javadoc",
+			filename:   "App.java",
+			content:    "/**/\nclass App {}\n",
+			code:       1,
+			comments:   1,
+			docstrings: 0,
+		},
+		{
+			name:       "rust doc comments",
+			filename:   "lib.rs",
+			content:    "//! Crate docs.\n/// Adds.\n// plain\nfn add() {}\n",
+			code:       1,
+			comments:   3,
+			docstrings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := filepath.Join(t.TempDir(), tt.filename)
+			os.WriteFile(tmpFile, []byte(tt.content), 0644)
+
+			got, err := CountLines(tmpFile)
+			if err != nil {
+				t.Fatalf("CountLines failed: %v", err)
+			}
+			if got.Code != tt.code {
+				t.Errorf("Code = %d, want %d", got.Code, tt.code)
+			}
+			if got.Comments != tt.comments {
+				t.Errorf("Comments = %d, want %d", got.Comments, tt.comments)
+			}
+			if got.Docstrings != tt.docstrings {
+				t.Errorf("Docstrings = %d, want %d", got.Docstrings, tt.docstrings)
+			}
+		})
+	}
+}
+
+func TestCountContent_Shape(t *testing.T) {
+	minified := "var a=1;" + strings.Repeat("function f(){return a}", 100) + "\n"
+	mapped := "const x = 1;\n//# sourceMappingURL=app.js.map\n"
+
+	c := countContentAs([]byte(minified), "JavaScript")
+	if c.shape.MaxLineLength != len(minified)-1 || c.shape.Whitespace != 201 {
+		t.Errorf("minified shape = %+v, want MaxLineLength %d, Whitespace 201", c.shape, len(minified)-1)
+	}
+
+	c = countContentAs([]byte(mapped), "JavaScript")
+	if !c.shape.SourceMap || c.shape.MaxLineLength != 31 || c.shape.Whitespace != 4 {
+		t.Errorf("mapped shape = %+v, want SourceMap, MaxLineLength 31, Whitespace 4", c.shape)
+	}
+}
+
+func TestCountFile_MappedMatchesStreamed(t *testing.T) {
+	chunk := "// Package big is large\npackage big\n\n/* block\n   comment */\nvar s = \"/* not a comment */\"\r\n"
+	content := strings.Repeat(chunk, mmapThreshold/len(chunk)+1)
+	path := filepath.Join(t.TempDir(), "big.go")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mapped, err := countFile(path, "Go", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bufPtr := bufferPool.Get().(*[]byte)
+	defer bufferPool.Put(bufPtr)
+	streamed, err := countReaderAs(strings.NewReader(content), "Go", bufPtr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := mmapThreshold/len(chunk) + 1
+	want := model.LineMetrics{Total: 6 * n, Blanks: n, Comments: 3 * n, Code: 2 * n}
+	if mapped.lines != want || streamed.lines != want {
+		t.Errorf("mapped = %+v, streamed = %+v; want %+v", mapped.lines, streamed.lines, want)
+	}
+	if mapped.shape != streamed.shape {
+		t.Errorf("mapped shape = %+v, streamed shape = %+v", mapped.shape, streamed.shape)
+	}
+}
@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// disambiguateBytes is how much of a file the content heuristics look at
+const disambiguateBytes = 8192
+
+// heuristic picks a language when its pattern matches a file's content
+type heuristic struct {
+	lang    string
+	pattern *regexp.Regexp
+}
+
+// ambiguousExtensions lists extensions used by several languages, with
+// content heuristics in the style of GitHub Linguist. The first matching
+// heuristic wins; when none match, neighbor files are consulted and finally
+// the extension's default language from languages.json is kept.
+var ambiguousExtensions = map[string][]heuristic{
+	"h": {
+		{"Objective-C", regexp.MustCompile(`(?m)^\s*(@(interface|implementation|protocol|property|end|class)\b|#import\s)`)},
+		{"C++ Header", regexp.MustCompile(`(?m)^\s*(class\s+\w+\s*[:{]|namespace\s+\w*\s*\{|template\s*<|using\s+namespace\s|#include\s*<(iostream|string|vector|map|memory|algorithm|cstdint|cstdlib)>)|\bstd::`)},
+	},
+	"m": {
+		{"Objective-C", regexp.MustCompile(`(?m)^\s*(@(interface|implementation|protocol|end|import)\b|#(import|include)\s)`)},
+		{"MATLAB", regexp.MustCompile(`(?m)^\s*(function\s+(\[[^\]]*\]|\w+)\s*=|function\s+\w+\s*(\(|$)|classdef\s|%|end\s*$|(disp|fprintf|plot|zeros|ones)\()`)},
+	},
+	"pl": {
+		{"Raku", regexp.MustCompile(`(?m)^\s*(use\s+v6\b|unit\s+(module|class)\s|my\s+class\s|grammar\s+\w+)`)},
+		{"Prolog", regexp.MustCompile(`(?m)^[^#\n]*:-|^\w+\([^)]*\)\s*\.\s*$`)},
+	},
+	"ts": {
+		{"XML", regexp.MustCompile(`\A\s*(<\?xml|<!DOCTYPE TS>|<TS\b)`)},
+	},
+	"v": {
+		{"Verilog", regexp.MustCompile(`(?m)^\s*(module\s+\w+\s*[(#;]|endmodule\b|always\s*@|` + "`" + `(timescale|define)\b)`)},
+	},
+}
+
+// neighborHints resolve a file by the extensions next to it when its content
+// is inconclusive, e.g. a bare .h beside .cpp files is a C++ header
+var neighborHints = map[string][]struct {
+	lang string
+	exts []string
+}{
+	"h": {
+		{"Objective-C", []string{".m", ".mm"}},
+		{"C++ Header", []string{".cpp", ".cc", ".cxx", ".hpp", ".hh"}},
+	},
+	"m": {
+		{"MATLAB", []string{".mat", ".mlx", ".fig"}},
+	},
+}
+
+// isAmbiguousPath reports whether a file's extension needs disambiguation
+func isAmbiguousPath(path string) bool {
+	_, ok := ambiguousExtensions[strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))]
+	return ok
+}
+
+// Disambiguate picks the language of a file with an ambiguous extension from
+// its content and, failing that, its neighbors. neighbors returns the set of
+// lowercase extensions in a directory and may be nil. ok is false when the
+// extension isn't ambiguous or neither content nor neighbors were conclusive.
+func Disambiguate(path string, content []byte, neighbors func(dir string) map[string]bool) (lang string, ok bool) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	rules, ambiguous := ambiguousExtensions[ext]
+	if !ambiguous {
+		return "", false
+	}
+
+	head := content[:min(len(content), disambiguateBytes)]
+	for _, h := range rules {
+		if h.pattern.Match(head) {
+			return h.lang, true
+		}
+	}
+
+	if neighbors != nil {
+		exts := neighbors(filepath.Dir(path))
+		for _, hint := range neighborHints[ext] {
+			for _, e := range hint.exts {
+				if exts[e] {
+					return hint.lang, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// dirExtensions lists the extensions in each directory of the working tree,
+// read once per directory
+type dirExtensions struct {
+	dirs sync.Map // dir -> map[string]bool
+}
+
+func (d *dirExtensions) lookup(dir string) map[string]bool {
+	if exts, ok := d.dirs.Load(dir); ok {
+		return exts.(map[string]bool)
+	}
+	exts := make(map[string]bool)
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if !e.IsDir() {
+			exts[strings.ToLower(filepath.Ext(e.Name()))] = true
+		}
+	}
+	d.dirs.Store(dir, exts)
+	return exts
+}
+
+// readHead reads up to n leading bytes of a file
+func readHead(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	read, err := f.Read(buf)
+	if err != nil && read == 0 {
+		return nil, nil // empty file
+	}
+	return buf[:read], nil
+}
@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDisambiguate_Content(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		wantLang string
+		wantOK   bool
+	}{
+		{"objc header", "Foo.h", "#import <Foundation/Foundation.h>\n@interface Foo : NSObject\n@end\n", "Objective-C", true},
+		{"cpp header", "vec.h", "#pragma once\nnamespace geo {\nclass Vec {};\n}\n", "C++ Header", true},
+		{"c header", "util.h", "#ifndef UTIL_H\nint add(int a, int b);\n#endif\n", "", false},
+		{"matlab function", "solve.m", "function x = solve(A, b)\n% least squares\nx = A \\ b;\nend\n", "MATLAB", true},
+		{"matlab script", "run.m", "data = zeros(10);\ndisp(data)\n", "MATLAB", true},
+		{"objc implementation", "Foo.m", "#import \"Foo.h\"\n@implementation Foo\n@end\n", "Objective-C", true},
+		{"prolog", "family.pl", "parent(tom, bob).\nancestor(X, Y) :- parent(X, Y).\n", "Prolog", true},
+		{"raku", "app.pl", "use v6;\nsay 'hi';\n", "Raku", true},
+		{"perl", "app.pl", "use strict;\nmy $x = 1;\n", "", false},
+		{"qt translation", "app_de.ts", "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<!DOCTYPE TS>\n<TS version=\"2.1\">\n", "XML", true},
+		{"typescript", "app.ts", "export const x = 1\n", "", false},
+		{"verilog", "alu.v", "module alu(input a, output b);\nendmodule\n", "Verilog", true},
+		{"not ambiguous", "main.go", "package main\n", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, ok := Disambiguate(tt.path, []byte(tt.content), nil)
+			if lang != tt.wantLang || ok != tt.wantOK {
+				t.Errorf("Disambiguate(%q) = %q, %v; want %q, %v", tt.path, lang, ok, tt.wantLang, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDisambiguate_Neighbors(t *testing.T) {
+	neighbors := func(dir string) map[string]bool {
+		if dir == filepath.FromSlash("src/geo") {
+			return map[string]bool{".cpp": true, ".h": true}
+		}
+		return nil
+	}
+
+	lang, ok := Disambiguate(filepath.FromSlash("src/geo/point.h"), []byte("struct point { int x, y; };\n"), neighbors)
+	if lang != "C++ Header" || !ok {
+		t.Errorf("Disambiguate beside .cpp = %q, %v; want C++ Header, true", lang, ok)
+	}
+	if lang, ok := Disambiguate(filepath.FromSlash("src/c/point.h"), []byte("struct point;\n"), neighbors); ok {
+		t.Errorf("Disambiguate without hints = %q, want no match", lang)
+	}
+}
+
+func TestScanner_DisambiguatesLanguage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"solve.m": "% solve a system\nfunction x = solve(A, b)\n  x = A \\ b; % backslash\nend\n",
+	})
+
+	files := scanWithCache(t, root, nil)
+	f := files["solve.m"]
+	if f.LanguageHint != "MATLAB" || !f.LanguageFromContent {
+		t.Fatalf("language = %q (from content: %v), want MATLAB from content", f.LanguageHint, f.LanguageFromContent)
+	}
+	// counted with MATLAB's % comments, not Objective-C's
+	if f.Lines.Comments != 1 || f.Lines.Code != 3 {
+		t.Errorf("lines = %+v, want 1 comment and 3 code", f.Lines)
+	}
+}
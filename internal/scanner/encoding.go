@@ -0,0 +1,259 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// textEncoding is the character encoding of a text file. Everything is
+// converted to UTF-8 before counting.
+type textEncoding int
+
+const (
+	encodingUTF8 textEncoding = iota
+	encodingUTF8BOM
+	encodingUTF16LE
+	encodingUTF16BE
+	encodingLatin1
+)
+
+// sniffBytes is how much of in-memory content is inspected for its encoding,
+// the same as a streamed file's first read
+const sniffBytes = 256 * 1024
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+func (e textEncoding) String() string {
+	switch e {
+	case encodingUTF8BOM:
+		return "UTF-8 with BOM"
+	case encodingUTF16LE:
+		return "UTF-16LE"
+	case encodingUTF16BE:
+		return "UTF-16BE"
+	case encodingLatin1:
+		return "Latin-1"
+	default:
+		return "UTF-8"
+	}
+}
+
+// bomLen is the length of the byte order mark at the start of head, if any
+func bomLen(head []byte) int {
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return len(bomUTF8)
+	case bytes.HasPrefix(head, bomUTF16LE), bytes.HasPrefix(head, bomUTF16BE):
+		return len(bomUTF16LE)
+	default:
+		return 0
+	}
+}
+
+// transcoded reports whether the encoding has to be converted to UTF-8
+func (e textEncoding) transcoded() bool {
+	return e == encodingUTF16LE || e == encodingUTF16BE || e == encodingLatin1
+}
+
+// sniffEncoding guesses the encoding of a file from its leading bytes.
+// binary is true for content with NUL bytes that isn't UTF-16 text.
+func sniffEncoding(head []byte) (enc textEncoding, binary bool) {
+	switch {
+	case bytes.HasPrefix(head, bomUTF8):
+		return encodingUTF8BOM, false
+	case bytes.HasPrefix(head, bomUTF16LE):
+		return encodingUTF16LE, false
+	case bytes.HasPrefix(head, bomUTF16BE):
+		return encodingUTF16BE, false
+	}
+
+	if isBinary(head) {
+		// BOM-less UTF-16 (common for files saved by Windows tools) has a
+		// NUL in every other byte for ASCII text
+		if enc, ok := sniffUTF16(head[:min(len(head), 512)]); ok {
+			return enc, false
+		}
+		return encodingUTF8, true
+	}
+
+	if !utf8.Valid(trimPartialRune(head)) {
+		return encodingLatin1, false
+	}
+	return encodingUTF8, false
+}
+
+// sniffUTF16 detects BOM-less UTF-16 by where its zero bytes fall: the high
+// byte of mostly-ASCII text is zero, the low byte almost never is
+func sniffUTF16(sample []byte) (textEncoding, bool) {
+	units := len(sample) / 2
+	if units < 2 {
+		return 0, false
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	switch {
+	case oddZeros*10 >= units*7 && evenZeros*10 < units:
+		return encodingUTF16LE, true
+	case evenZeros*10 >= units*7 && oddZeros*10 < units:
+		return encodingUTF16BE, true
+	}
+	return 0, false
+}
+
+// trimPartialRune drops a multi-byte character cut off at the end of a
+// buffer, so a truncated head of a UTF-8 file still validates
+func trimPartialRune(b []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return b[:len(b)-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// DecodeError reports a text file that could not be decoded cleanly. It is
+// not fatal: the file is still counted, with the malformed characters
+// replaced by U+FFFD.
+type DecodeError struct {
+	Path      string
+	Encoding  string
+	Invalid   int  // unpaired surrogates
+	Truncated bool // odd trailing byte in UTF-16
+}
+
+func (e *DecodeError) Error() string {
+	var problems []string
+	if e.Invalid > 0 {
+		problems = append(problems, fmt.Sprintf("%d invalid characters", e.Invalid))
+	}
+	if e.Truncated {
+		problems = append(problems, "truncated final character")
+	}
+	return fmt.Sprintf("%s: malformed %s text: %s", e.Path, e.Encoding, strings.Join(problems, ", "))
+}
+
+// textDecoder converts a UTF-16 or Latin-1 stream (after any BOM) to UTF-8
+type textDecoder struct {
+	r       io.Reader
+	enc     textEncoding
+	src     []byte
+	nsrc    int // undecoded bytes carried over at the front of src
+	out     []byte
+	pending []byte // decoded bytes not yet returned by Read
+	err     error
+
+	invalid   int
+	truncated bool
+}
+
+func newTextDecoder(r io.Reader, enc textEncoding) *textDecoder {
+	return &textDecoder{r: r, enc: enc, src: make([]byte, 32*1024)}
+}
+
+func (d *textDecoder) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		n, err := d.r.Read(d.src[d.nsrc:])
+		d.nsrc += n
+		d.err = err
+		d.decode(err != nil)
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// decode converts the buffered source bytes; final flushes a dangling
+// byte or surrogate at the end of the input
+func (d *textDecoder) decode(final bool) {
+	out := d.out[:0]
+	src := d.src[:d.nsrc]
+
+	if d.enc == encodingLatin1 {
+		for _, b := range src {
+			out = utf8.AppendRune(out, rune(b))
+		}
+		src = src[len(src):]
+	} else {
+		var order binary.ByteOrder = binary.LittleEndian
+		if d.enc == encodingUTF16BE {
+			order = binary.BigEndian
+		}
+		for len(src) >= 2 {
+			u := rune(order.Uint16(src))
+			if !utf16.IsSurrogate(u) {
+				out = utf8.AppendRune(out, u)
+				src = src[2:]
+				continue
+			}
+			if len(src) < 4 && !final {
+				break // the pair's second half is in the next read
+			}
+			r := utf8.RuneError
+			if len(src) >= 4 {
+				r = utf16.DecodeRune(u, rune(order.Uint16(src[2:])))
+			}
+			if r == utf8.RuneError {
+				d.invalid++
+				out = utf8.AppendRune(out, utf8.RuneError)
+				src = src[2:]
+				continue
+			}
+			out = utf8.AppendRune(out, r)
+			src = src[4:]
+		}
+		if final && len(src) > 0 {
+			d.truncated = true
+			src = src[len(src):]
+		}
+	}
+
+	d.nsrc = copy(d.src, src)
+	d.out = out
+	d.pending = out
+}
+
+// decodeErr returns the problems seen so far, or nil
+func (d *textDecoder) decodeErr() *DecodeError {
+	if d.invalid == 0 && !d.truncated {
+		return nil
+	}
+	return &DecodeError{Encoding: d.enc.String(), Invalid: d.invalid, Truncated: d.truncated}
+}
+
+// decodeContent converts in-memory text to UTF-8 without a BOM. Binary
+// content is returned unchanged.
+func decodeContent(content []byte) ([]byte, *DecodeError) {
+	enc, binary := sniffEncoding(content[:min(len(content), sniffBytes)])
+	if binary {
+		return content, nil
+	}
+	content = content[bomLen(content):]
+	if !enc.transcoded() {
+		return content, nil
+	}
+	d := newTextDecoder(bytes.NewReader(content), enc)
+	text, _ := io.ReadAll(d)
+	return text, d.decodeErr()
+}
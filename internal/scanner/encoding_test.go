@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// utf16le encodes s as UTF-16LE, optionally with a byte order mark
+func utf16le(s string, bom bool) string {
+	var b []byte
+	if bom {
+		b = append(b, bomUTF16LE...)
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return string(b)
+}
+
+func TestLineReader_LongLines(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	input := "a\r\n" + long + "\n\n" + long + "\r\nlast"
+
+	l := newLineReader(strings.NewReader(input), make([]byte, 16))
+	var got []string
+	for l.Scan() {
+		got = append(got, l.Text())
+	}
+	if l.Err() != nil {
+		t.Fatal(l.Err())
+	}
+
+	want := []string{"a", long, "", long, "last"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSniffEncoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		want       textEncoding
+		wantBinary bool
+	}{
+		{"ascii", "int x;\n", encodingUTF8, false},
+		{"utf-8", "// héllo\n", encodingUTF8, false},
+		{"utf-8 bom", "\xEF\xBB\xBFusing System;\n", encodingUTF8BOM, false},
+		{"utf-16le bom", utf16le("SELECT 1;\n", true), encodingUTF16LE, false},
+		{"utf-16be bom", "\xFE\xFF\x00S\x00E\x00L", encodingUTF16BE, false},
+		{"utf-16le without bom", utf16le("namespace App {}\n", false), encodingUTF16LE, false},
+		{"latin-1", "-- caf\xe9\nSELECT 1;\n", encodingLatin1, false},
+		{"utf-8 cut mid-character", "// caf\xc3", encodingUTF8, false},
+		{"binary", "\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02\x00", encodingUTF8, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, binary := sniffEncoding([]byte(tt.content))
+			if enc != tt.want || binary != tt.wantBinary {
+				t.Errorf("sniffEncoding = %v, %v; want %v, %v", enc, binary, tt.want, tt.wantBinary)
+			}
+		})
+	}
+}
+
+func TestScanner_Encodings(t *testing.T) {
+	root := t.TempDir()
+	csharp := "// greeting\nusing System;\n\nclass Hello {}\n"
+	writeFiles(t, root, map[string]string{
+		"Hello.cs":      utf16le(csharp, true),
+		"NoBom.cs":      utf16le(csharp, false),
+		"Bom.cs":        "\xEF\xBB\xBF" + csharp,
+		"schema.sql":    "-- caf\xe9\r\nSELECT 1;\r\n",
+		"bundle.min.js": "var a=1;" + strings.Repeat("a+=1;", 200_000) + "\nvar b=2;\n",
+	})
+
+	files := scanWithCache(t, root, nil)
+
+	want := model.LineMetrics{Total: 4, Blanks: 1, Comments: 1, Code: 2}
+	for _, name := range []string{"Hello.cs", "NoBom.cs", "Bom.cs"} {
+		f, ok := files[name]
+		if !ok {
+			t.Errorf("%s missing from scan", name)
+			continue
+		}
+		if f.Lines != want {
+			t.Errorf("%s lines = %+v, want %+v", name, f.Lines, want)
+		}
+	}
+
+	if got := files["schema.sql"].Lines; got != (model.LineMetrics{Total: 2, Comments: 1, Code: 1}) {
+		t.Errorf("schema.sql lines = %+v, want 1 comment and 1 code", got)
+	}
+
+	// a 1MB line no longer stops counting mid-file
+	bundle := files["bundle.min.js"]
+	if bundle.Lines.Total != 2 || bundle.Shape.MaxLineLength != 8+5*200_000 {
+		t.Errorf("bundle.min.js lines = %+v, shape = %+v; want 2 lines, longest %d", bundle.Lines, bundle.Shape, 8+5*200_000)
+	}
+}
+
+func TestScanner_ReportsDecodeErrors(t *testing.T) {
+	root := t.TempDir()
+	// an unpaired high surrogate and an odd trailing byte
+	writeFiles(t, root, map[string]string{
+		"Broken.cs": utf16le("int a;\n", true) + "\x00\xD8b\x00;\x00\n\x00x",
+	})
+
+	s, err := NewScanner(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, errs := s.Scan(context.Background())
+	var files []*model.RawFile
+	for f := range results {
+		files = append(files, f)
+	}
+	var scanErrs []error
+	for err := range errs {
+		scanErrs = append(scanErrs, err)
+	}
+
+	if len(files) != 1 || files[0].Lines.Total != 2 {
+		t.Fatalf("files = %v, want Broken.cs counted with 2 lines", files)
+	}
+	if len(scanErrs) != 1 {
+		t.Fatalf("errors = %v, want one decode error", scanErrs)
+	}
+	var decodeErr *DecodeError
+	if !errors.As(scanErrs[0], &decodeErr) {
+		t.Fatalf("error = %v, want *DecodeError", scanErrs[0])
+	}
+	if decodeErr.Path != filepath.FromSlash("Broken.cs") || decodeErr.Invalid != 1 || !decodeErr.Truncated {
+		t.Errorf("DecodeError = %+v, want Broken.cs with 1 invalid character, truncated", decodeErr)
+	}
+}
@@ -0,0 +1,257 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// GitAttributes holds the linguist-* attributes from .gitattributes files,
+// stacked per directory as git applies them: deeper files override shallower
+// ones, later lines override earlier ones, and .git/info/attributes
+// overrides them all. Nested files are added with LoadDir as the walker
+// descends; Match may run concurrently with it.
+type GitAttributes struct {
+	root string
+	info []attrRule // .git/info/attributes
+
+	mu   sync.RWMutex
+	dirs map[string][]attrRule // by directory relative to root ("" = root)
+}
+
+type attrRule struct {
+	pattern  string
+	anchored bool
+	attrs    []attrSetting
+}
+
+// attrSetting is one attribute of a line: "attr" sets it to "true",
+// "-attr" to "false", "!attr" unspecifies it (unset is true), and
+// "attr=value" sets a value
+type attrSetting struct {
+	name  string
+	value string
+	unset bool
+}
+
+// linguistAttributes are the attributes aloc reads; others are ignored
+var linguistAttributes = map[string]bool{
+	"linguist-generated":     true,
+	"linguist-vendored":      true,
+	"linguist-documentation": true,
+	"linguist-language":      true,
+}
+
+// LoadGitAttributes loads .git/info/attributes and the root's .gitattributes
+func LoadGitAttributes(root string) (*GitAttributes, error) {
+	ga := &GitAttributes{root: root, dirs: make(map[string][]attrRule)}
+
+	if file := infoAttributesFile(root); file != "" {
+		rules, err := loadAttributesFile(file)
+		if err != nil {
+			return nil, err
+		}
+		ga.info = rules
+	}
+
+	if err := ga.LoadDir(root); err != nil {
+		return nil, err
+	}
+	return ga, nil
+}
+
+// LoadDir loads the .gitattributes in dir so it applies to its descendants.
+// Loading the same directory twice is a no-op.
+func (ga *GitAttributes) LoadDir(dir string) error {
+	rel, err := filepath.Rel(ga.root, dir)
+	if err != nil {
+		return err
+	}
+	rules, err := loadAttributesFile(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return err
+	}
+	ga.add(rel, rules)
+	return nil
+}
+
+// add sets the rules of the .gitattributes in a root-relative directory
+func (ga *GitAttributes) add(rel string, rules []attrRule) {
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	ga.mu.Lock()
+	defer ga.mu.Unlock()
+	if _, loaded := ga.dirs[rel]; !loaded {
+		ga.dirs[rel] = rules
+	}
+}
+
+// infoAttributesFile returns the repository's info/attributes path
+func infoAttributesFile(root string) string {
+	exclude := infoExcludeFile(root)
+	if exclude == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(exclude), "attributes")
+}
+
+// loadAttributesFile parses a single attributes file, keeping only lines
+// that set linguist attributes
+func loadAttributesFile(path string) ([]attrRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) || os.IsPermission(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return parseAttributes(f)
+}
+
+func parseAttributes(r io.Reader) ([]attrRule, error) {
+	var rules []attrRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// macro definitions ([attr]name ...) only matter for the attributes they expand to
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+
+		var rule attrRule
+		for _, field := range fields[1:] {
+			var s attrSetting
+			switch {
+			case strings.HasPrefix(field, "-"):
+				s = attrSetting{name: field[1:], value: "false"}
+			case strings.HasPrefix(field, "!"):
+				s = attrSetting{name: field[1:], unset: true}
+			default:
+				name, value, ok := strings.Cut(field, "=")
+				if !ok {
+					value = "true"
+				}
+				s = attrSetting{name: name, value: value}
+			}
+			if linguistAttributes[s.name] {
+				rule.attrs = append(rule.attrs, s)
+			}
+		}
+		if len(rule.attrs) == 0 {
+			continue
+		}
+
+		// as in .gitignore, a slash anywhere but the end anchors the pattern
+		// to the file's directory
+		rule.pattern = fields[0]
+		if strings.HasPrefix(rule.pattern, "/") {
+			rule.anchored = true
+			rule.pattern = rule.pattern[1:]
+		} else if strings.Contains(rule.pattern, "/") {
+			rule.anchored = true
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// Match returns the linguist attributes of a root-relative file path
+func (ga *GitAttributes) Match(relPath string) model.Linguist {
+	if ga == nil {
+		return model.Linguist{}
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	values := make(map[string]string)
+	apply := func(rules []attrRule, rel string) {
+		for _, r := range rules {
+			if !r.matches(rel) {
+				continue
+			}
+			for _, s := range r.attrs {
+				if s.unset {
+					delete(values, s.name)
+				} else {
+					values[s.name] = s.value
+				}
+			}
+		}
+	}
+
+	// walk attribute files from the root down to the file's directory,
+	// matching each against the path relative to that file's directory
+	ga.mu.RLock()
+	apply(ga.dirs[""], relPath)
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if rules := ga.dirs[strings.Join(parts[:i], "/")]; len(rules) > 0 {
+			apply(rules, strings.Join(parts[i:], "/"))
+		}
+	}
+	ga.mu.RUnlock()
+	apply(ga.info, relPath)
+
+	return model.Linguist{
+		Generated:     isTrue(values["linguist-generated"]),
+		Vendored:      isTrue(values["linguist-vendored"]),
+		Documentation: isTrue(values["linguist-documentation"]),
+		Language:      linguistLanguage(values["linguist-language"]),
+	}
+}
+
+// matches reports whether the rule's pattern matches rel, the path relative
+// to the attributes file's directory. Unlike .gitignore, a pattern naming a
+// directory does not match the files inside it; that takes "dir/**".
+func (r attrRule) matches(rel string) bool {
+	if r.anchored {
+		return matchPattern(r.pattern, rel)
+	}
+	ok, _ := path.Match(r.pattern, path.Base(rel))
+	return ok
+}
+
+func isTrue(value string) bool {
+	return value != "" && value != "false"
+}
+
+// linguistLanguage maps a linguist language name (case-insensitive, with
+// GitHub's "-" for spaces, e.g. "Objective-C" or "visual-basic") to a
+// language of the language table, or "" when there is none
+func linguistLanguage(name string) string {
+	if name == "" {
+		return ""
+	}
+	if _, ok := languages[name]; ok {
+		return name
+	}
+	for lang := range languages {
+		if strings.EqualFold(lang, name) || strings.EqualFold(strings.ReplaceAll(lang, " ", "-"), name) {
+			return lang
+		}
+	}
+	return ""
+}
+
+// treeAttributes builds the attributes of a git tree from the blobs of its
+// .gitattributes files, keyed by their root-relative path
+func treeAttributes(root string, files map[string][]byte) *GitAttributes {
+	ga := &GitAttributes{root: root, dirs: make(map[string][]attrRule)}
+	if file := infoAttributesFile(root); file != "" {
+		ga.info, _ = loadAttributesFile(file)
+	}
+	for relPath, content := range files {
+		rules, _ := parseAttributes(bytes.NewReader(content))
+		ga.add(filepath.Dir(relPath), rules)
+	}
+	return ga
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestGitAttributes_Match(t *testing.T) {
+	rules := func(text string) []attrRule {
+		r, err := parseAttributes(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	ga := &GitAttributes{dirs: map[string][]attrRule{
+		"": rules(`# generated code
+*.pb.go linguist-generated
+third_party/** linguist-vendored=true
+docs/** linguist-documentation
+docs/internal/** !linguist-documentation
+*.h linguist-language=C++ text eol=lf
+*.vb linguist-language=visual-basic
+[attr]gen linguist-generated
+`),
+		"api": rules("legacy.pb.go -linguist-generated\n/client.go linguist-generated=true\n"),
+	}}
+	ga.info = rules("api/client.go linguist-generated=false\n")
+
+	tests := []struct {
+		path string
+		want model.Linguist
+	}{
+		{"pb/user.pb.go", model.Linguist{Generated: true}},
+		{"api/user.pb.go", model.Linguist{Generated: true}},
+		// a deeper file overrides the root's, and info/attributes overrides both
+		{"api/legacy.pb.go", model.Linguist{}},
+		{"api/client.go", model.Linguist{}},
+		{"third_party/zlib/zlib.c", model.Linguist{Vendored: true}},
+		{"docs/guide.md", model.Linguist{Documentation: true}},
+		{"docs/internal/notes.md", model.Linguist{}},
+		{"include/util.h", model.Linguist{Language: "C++"}},
+		{"legacy/form.vb", model.Linguist{Language: "Visual Basic"}},
+		// a directory pattern doesn't reach the files inside it
+		{"third_party", model.Linguist{}},
+		{"main.go", model.Linguist{}},
+	}
+
+	for _, tt := range tests {
+		if got := ga.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestScanner_GitAttributes(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitattributes":             "*.pb.go linguist-generated\nshared/*.h linguist-language=C++\n",
+		".git/info/attributes":       "pb/legacy.pb.go -linguist-generated\n",
+		"pb/user.pb.go":              "package pb\n",
+		"pb/legacy.pb.go":            "package pb\n",
+		"docs/.gitattributes":        "*.go linguist-documentation\n",
+		"docs/example.go":            "package docs\n",
+		"shared/util.h":              "int util(void);\n",
+		"third_party/.keep.go":       "package keep\n",
+		"third_party/.gitattributes": "** linguist-vendored\n",
+	})
+	mtime := time.Now().Add(-time.Hour)
+	setMtime(t, filepath.Join(root, "shared/util.h"), mtime)
+
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+	cache := OpenCache(cachePath, "test")
+	files := scanWith(t, root, Options{Cache: cache})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]model.Linguist{
+		"pb/user.pb.go":        {Generated: true},
+		"pb/legacy.pb.go":      {},
+		"docs/example.go":      {Documentation: true},
+		"shared/util.h":        {Language: "C++"},
+		"third_party/.keep.go": {Vendored: true},
+	}
+	for path, linguist := range want {
+		f, ok := files[path]
+		if !ok {
+			t.Errorf("%s not scanned", path)
+			continue
+		}
+		if f.Linguist != linguist {
+			t.Errorf("%s linguist = %+v, want %+v", path, f.Linguist, linguist)
+		}
+	}
+	if lang := files["shared/util.h"].LanguageHint; lang != "C++" {
+		t.Errorf("util.h language = %q, want C++ from linguist-language", lang)
+	}
+
+	// a cached count made under another language attribute is not reused
+	writeFiles(t, root, map[string]string{".gitattributes": "*.pb.go linguist-generated\nshared/*.h linguist-language=Objective-C\n"})
+	files = scanWith(t, root, Options{Cache: OpenCache(cachePath, "test")})
+	if lang := files["shared/util.h"].LanguageHint; lang != "Objective-C" {
+		t.Errorf("util.h language after the attribute changed = %q, want Objective-C", lang)
+	}
+	if err := os.Remove(filepath.Join(root, ".gitattributes")); err != nil {
+		t.Fatal(err)
+	}
+	files = scanWith(t, root, Options{Cache: OpenCache(cachePath, "test")})
+	if f := files["shared/util.h"]; f.Linguist.Language != "" || f.LanguageHint == "Objective-C" {
+		t.Errorf("util.h after the attribute was removed = %q (linguist %+v), want detected", f.LanguageHint, f.Linguist)
+	}
+}
+
+func TestRevScanner_GitAttributes(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitattributes":     "*.pb.go linguist-generated\n",
+		"api/.gitattributes": "legacy.pb.go -linguist-generated\n*.h linguist-language=C++\n",
+		"api/user.pb.go":     "package api\n",
+		"api/legacy.pb.go":   "package api\n",
+		"api/util.h":         "int util(void);\n",
+	})
+	gitCmd(t, root, "init", "-q")
+	gitCmd(t, root, "add", "-A")
+	gitCmd(t, root, "commit", "-q", "-m", "initial")
+
+	files := scanRev(t, root, "HEAD", Options{})
+	if !files["api/user.pb.go"].Linguist.Generated {
+		t.Error("api/user.pb.go should be linguist-generated")
+	}
+	if files["api/legacy.pb.go"].Linguist.Generated {
+		t.Error("api/legacy.pb.go has linguist-generated unset by api/.gitattributes")
+	}
+	if f := files["api/util.h"]; f.LanguageHint != "C++" {
+		t.Errorf("api/util.h language = %q, want C++", f.LanguageHint)
+	}
+}
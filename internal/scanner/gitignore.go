@@ -3,14 +3,25 @@ package scanner
 import (
 	"bufio"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
-// GitIgnore holds parsed gitignore patterns
+// ignoreFilenames are the per-directory ignore files, in precedence order
+// (.ignore is loaded second so its patterns win over .gitignore)
+var ignoreFilenames = []string{".gitignore", ".ignore"}
+
+// GitIgnore holds parsed gitignore patterns stacked per directory, following
+// git's precedence: core.excludesFile < .git/info/exclude < .gitignore files
+// from the root down to the file's own directory. The last matching pattern wins.
+// Nested ignore files are added with LoadDir as the walker descends; GitIgnore
+// is not safe for concurrent use.
 type GitIgnore struct {
-	patterns []gitignorePattern
-	root     string
+	root   string
+	global []gitignorePattern            // core.excludesFile and .git/info/exclude
+	dirs   map[string][]gitignorePattern // by directory relative to root ("" = root)
 }
 
 type gitignorePattern struct {
@@ -20,28 +31,108 @@ type gitignorePattern struct {
 	anchored bool
 }
 
-// LoadGitIgnore loads and parses ignore files (.gitignore and .ignore)
-// Patterns from .ignore take precedence over .gitignore (loaded second)
+// LoadGitIgnore loads the user's core.excludesFile, .git/info/exclude and the
+// root's ignore files (.gitignore and .ignore)
 func LoadGitIgnore(root string) (*GitIgnore, error) {
-	gi := &GitIgnore{root: root}
+	gi := &GitIgnore{root: root, dirs: make(map[string][]gitignorePattern)}
 
-	// load in order: .gitignore, then .ignore (later files take precedence)
-	for _, filename := range []string{".gitignore", ".ignore"} {
-		patterns, err := loadIgnoreFile(filepath.Join(root, filename))
+	for _, file := range []string{globalExcludesFile(root), infoExcludeFile(root)} {
+		if file == "" {
+			continue
+		}
+		patterns, err := loadIgnoreFile(file)
 		if err != nil {
 			return nil, err
 		}
-		gi.patterns = append(gi.patterns, patterns...)
+		gi.global = append(gi.global, patterns...)
 	}
 
+	if err := gi.LoadDir(root); err != nil {
+		return nil, err
+	}
 	return gi, nil
 }
 
+// LoadDir loads the ignore files in dir so they apply to its descendants.
+// Loading the same directory twice is a no-op.
+func (gi *GitIgnore) LoadDir(dir string) error {
+	rel, err := filepath.Rel(gi.root, dir)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	if _, loaded := gi.dirs[rel]; loaded {
+		return nil
+	}
+
+	var patterns []gitignorePattern
+	for _, filename := range ignoreFilenames {
+		p, err := loadIgnoreFile(filepath.Join(dir, filename))
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, p...)
+	}
+	gi.dirs[rel] = patterns
+	return nil
+}
+
+// globalExcludesFile returns the user's core.excludesFile, defaulting to
+// $XDG_CONFIG_HOME/git/ignore as git does
+func globalExcludesFile(root string) string {
+	out, err := exec.Command("git", "-C", root, "config", "--path", "--get", "core.excludesFile").Output()
+	if err == nil {
+		if file := strings.TrimSpace(string(out)); file != "" {
+			return file
+		}
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "git", "ignore")
+}
+
+// infoExcludeFile returns the repository's info/exclude path, following a
+// "gitdir:" pointer when .git is a file (worktrees and submodules)
+func infoExcludeFile(root string) string {
+	gitPath := filepath.Join(root, ".git")
+	info, err := os.Stat(gitPath)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return filepath.Join(gitPath, "info", "exclude")
+	}
+
+	data, err := os.ReadFile(gitPath)
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+	return filepath.Join(gitDir, "info", "exclude")
+}
+
 // loadIgnoreFile parses a single ignore file and returns its patterns
 func loadIgnoreFile(path string) ([]gitignorePattern, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) || os.IsPermission(err) {
 			return nil, nil
 		}
 		return nil, err
@@ -63,10 +154,12 @@ func loadIgnoreFile(path string) ([]gitignorePattern, error) {
 func parsePattern(line string) gitignorePattern {
 	p := gitignorePattern{pattern: line}
 
-	// Check for negation
+	// Check for negation (a leading "\" escapes a literal "!" or "#")
 	if strings.HasPrefix(line, "!") {
 		p.negated = true
 		p.pattern = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		p.pattern = line[1:]
 	}
 
 	// Check for directory-only
@@ -75,7 +168,8 @@ func parsePattern(line string) gitignorePattern {
 		p.pattern = strings.TrimSuffix(p.pattern, "/")
 	}
 
-	// Check for anchored patterns
+	// Check for anchored patterns: a slash at the start or in the middle
+	// makes the pattern relative to the ignore file's directory
 	if strings.HasPrefix(p.pattern, "/") {
 		p.anchored = true
 		p.pattern = p.pattern[1:]
@@ -88,54 +182,83 @@ func parsePattern(line string) gitignorePattern {
 
 // Match checks if a path should be ignored
 func (gi *GitIgnore) Match(path string, isDir bool) bool {
-	if len(gi.patterns) == 0 {
-		return false
-	}
-
 	relPath, err := filepath.Rel(gi.root, path)
-	if err != nil {
+	if err != nil || relPath == "." {
 		return false
 	}
 	relPath = filepath.ToSlash(relPath)
 
 	ignored := false
-	for _, p := range gi.patterns {
-		if p.dirOnly && !isDir {
-			continue
+	apply := func(patterns []gitignorePattern, rel string) {
+		for _, p := range patterns {
+			if p.matches(rel, isDir) {
+				ignored = !p.negated
+			}
 		}
+	}
 
-		matched := matchPattern(p.pattern, relPath, p.anchored)
-		if matched {
-			ignored = !p.negated
+	apply(gi.global, relPath)
+
+	// walk ignore files from the root down to the path's parent directory,
+	// matching each against the path relative to that file's directory
+	apply(gi.dirs[""], relPath)
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		if patterns := gi.dirs[dir]; len(patterns) > 0 {
+			apply(patterns, strings.Join(parts[i:], "/"))
 		}
 	}
 	return ignored
 }
 
-func matchPattern(pattern, path string, anchored bool) bool {
-	// Simple glob matching
-	if anchored {
-		matched, _ := filepath.Match(pattern, path)
-		if matched {
-			return true
+// matches reports whether the pattern matches rel (relative to the pattern's
+// directory) or any of its parent directories
+func (p gitignorePattern) matches(rel string, isDir bool) bool {
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		// every prefix but the full path is a directory
+		if p.dirOnly && i == len(parts)-1 && !isDir {
+			continue
 		}
-		// Also try matching just the first part for directory patterns
-		parts := strings.Split(path, "/")
-		if len(parts) > 0 {
-			matched, _ = filepath.Match(pattern, parts[0])
-			if matched {
+		if p.anchored {
+			if matchPattern(p.pattern, strings.Join(parts[:i+1], "/")) {
 				return true
 			}
+		} else if ok, _ := path.Match(p.pattern, parts[i]); ok {
+			return true
 		}
-	} else {
-		// Match against any path component
-		parts := strings.Split(path, "/")
-		for _, part := range parts {
-			matched, _ := filepath.Match(pattern, part)
-			if matched {
+	}
+	return false
+}
+
+// matchPattern matches a slash-separated glob against a path, where a "**"
+// segment matches zero or more directories
+func matchPattern(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		// trailing "/**" matches everything inside, but not the directory itself
+		if len(pattern) == 1 {
+			return len(parts) > 0
+		}
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
 				return true
 			}
 		}
+		return false
 	}
-	return false
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
 }
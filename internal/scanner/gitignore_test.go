@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// isolateGitConfig keeps the developer's global git config out of tests
+func isolateGitConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	return home
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func walkAll(t *testing.T, root string) []string {
+	t.Helper()
+	w, err := NewWalker(root, WalkOptions{DeepMode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, errs := w.Walk(context.Background())
+	var got []string
+	for p := range paths {
+		rel, _ := filepath.Rel(w.root, p)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	for err := range errs {
+		t.Errorf("walk error: %v", err)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestGitIgnore_NestedFiles(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":          "*.log\n",
+		"main.go":             "package main\n",
+		"debug.log":           "x\n",
+		"web/.gitignore":      "/public/\n!keep.log\n",
+		"web/app.js":          "x\n",
+		"web/keep.log":        "x\n",
+		"web/public/app.js":   "x\n",
+		"web/src/public/a.js": "x\n", // anchored pattern only matches web/public
+	})
+
+	got := walkAll(t, root)
+	want := []string{".gitignore", "main.go", "web/.gitignore", "web/app.js", "web/keep.log", "web/src/public/a.js"}
+	assertPaths(t, got, want)
+}
+
+func TestGitIgnore_InfoExclude(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/info/exclude": "scratch/\n",
+		"main.go":           "package main\n",
+		"scratch/notes.go":  "package scratch\n",
+	})
+
+	got := walkAll(t, root)
+	assertPaths(t, got, []string{"main.go"})
+}
+
+func TestGitIgnore_GlobalExcludesFile(t *testing.T) {
+	home := isolateGitConfig(t)
+	excludes := filepath.Join(home, "global-ignore")
+	writeFiles(t, home, map[string]string{
+		"global-ignore": "*.swp\n",
+		".gitconfig":    "[core]\n\texcludesFile = " + filepath.ToSlash(excludes) + "\n",
+	})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":     "package main\n",
+		"main.go.swp": "x\n",
+	})
+
+	got := walkAll(t, root)
+	assertPaths(t, got, []string{"main.go"})
+}
+
+func TestGitIgnore_NestedOverridesParent(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":        "*.gen.go\n",
+		"api/.gitignore":    "!*.gen.go\n",
+		"api/types.gen.go":  "package api\n",
+		"core/types.gen.go": "package core\n",
+		"core/service.go":   "package core\n",
+	})
+
+	got := walkAll(t, root)
+	want := []string{".gitignore", "api/.gitignore", "api/types.gen.go", "core/service.go"}
+	assertPaths(t, got, want)
+}
+
+func TestGitIgnore_DoubleStar(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"**/out", "out", true},
+		{"**/out", "a/b/out", true},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**", "a", false},
+		{"a/**", "a/x", true},
+		{"a/*.js", "a/b/c.js", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func assertPaths(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("paths = %v, want %v", got, want)
+			return
+		}
+	}
+}
@@ -2,8 +2,10 @@ package scanner
 
 import (
 	"bufio"
+	"bytes"
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,11 +19,15 @@ type LanguageConfig struct {
 	Name              string     `json:"name"`
 	LineComment       []string   `json:"line_comment"`
 	MultiLineComments [][]string `json:"multi_line_comments"`
+	Quotes            [][]string `json:"quotes"`
+	VerbatimQuotes    [][]string `json:"verbatim_quotes"`
+	DocQuotes         [][]string `json:"doc_quotes"`
 	Extensions        []string   `json:"extensions"`
 	Filenames         []string   `json:"filenames"`
 	Shebangs          []string   `json:"shebangs"`
 	Env               []string   `json:"env"`
 	Nested            bool       `json:"nested"`
+	NestedComments    [][]string `json:"nested_comments"`
 	Blank             bool       `json:"blank"`
 	Literate          bool       `json:"literate"`
 	Category          string     `json:"category"`
@@ -61,6 +67,7 @@ func init() {
 			displayName = id
 		}
 		cfg.Name = displayName
+		normalizeMarkers(&cfg)
 		languages[displayName] = cfg
 
 		// map extensions to language
@@ -89,6 +96,123 @@ func init() {
 	}
 }
 
+// userLanguagesStamp fingerprints the languages added by RegisterLanguages,
+// so cached counts are invalidated when they change
+var userLanguagesStamp string
+
+// RegisterLanguages merges user-defined languages over the embedded table.
+// A definition whose Name matches a built-in language (case-insensitively)
+// extends it: comment markers, quotes and category replace the built-in ones
+// when set. Extensions and filenames are mapped to the definition even if
+// another language claimed them. Markers are taken literally (unescaped).
+// It must be called before scanning starts.
+func RegisterLanguages(defs []LanguageConfig) error {
+	for _, def := range defs {
+		if err := validateLanguage(def); err != nil {
+			return err
+		}
+	}
+
+	for _, def := range defs {
+		cfg, exists := languages[def.Name]
+		if !exists {
+			for name, c := range languages {
+				if strings.EqualFold(name, def.Name) {
+					cfg, exists = c, true
+					break
+				}
+			}
+		}
+		if !exists {
+			cfg = LanguageConfig{Name: def.Name, Category: "other"}
+		}
+
+		if def.LineComment != nil {
+			cfg.LineComment = def.LineComment
+		}
+		if def.MultiLineComments != nil {
+			cfg.MultiLineComments = def.MultiLineComments
+			cfg.Nested = def.Nested
+		}
+		if def.Quotes != nil {
+			cfg.Quotes = def.Quotes
+		}
+		if def.Category != "" {
+			cfg.Category = def.Category
+		}
+		if def.LineComment != nil || def.MultiLineComments != nil {
+			cfg.Blank = false
+		}
+
+		for _, ext := range def.Extensions {
+			ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+			cfg.Extensions = append(cfg.Extensions, ext)
+			extToLang[ext] = cfg.Name
+		}
+		for _, fname := range def.Filenames {
+			cfg.Filenames = append(cfg.Filenames, fname)
+			filenameToLang[strings.ToLower(fname)] = cfg.Name
+		}
+		languages[cfg.Name] = cfg
+	}
+
+	stamp, err := json.Marshal(defs)
+	if err != nil {
+		return err
+	}
+	userLanguagesStamp = string(stamp)
+	return nil
+}
+
+func validateLanguage(def LanguageConfig) error {
+	if def.Name == "" {
+		return fmt.Errorf("language definition without a name")
+	}
+	for _, pairs := range [][][]string{def.MultiLineComments, def.Quotes} {
+		for _, pair := range pairs {
+			if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+				return fmt.Errorf("language %q: delimiter pairs need an opening and a closing marker, got %q", def.Name, pair)
+			}
+		}
+	}
+	if def.Category != "" {
+		if _, ok := categoryFromString[def.Category]; !ok {
+			return fmt.Errorf("language %q: unknown category %q", def.Name, def.Category)
+		}
+	}
+	return nil
+}
+
+// normalizeMarkers unescapes comment and quote markers, which languages.json
+// stores in escaped form (e.g. `\"` for a double quote)
+func normalizeMarkers(cfg *LanguageConfig) {
+	for i, m := range cfg.LineComment {
+		cfg.LineComment[i] = unescapeMarker(m)
+	}
+	for _, pairs := range [][][]string{cfg.MultiLineComments, cfg.NestedComments, cfg.Quotes, cfg.VerbatimQuotes, cfg.DocQuotes} {
+		for _, pair := range pairs {
+			for i, m := range pair {
+				pair[i] = unescapeMarker(m)
+			}
+		}
+	}
+}
+
+// unescapeMarker resolves backslash escapes: `\"` -> `"`, `\\` -> `\`
+func unescapeMarker(m string) string {
+	if !strings.Contains(m, `\`) {
+		return m
+	}
+	var b strings.Builder
+	for i := 0; i < len(m); i++ {
+		if m[i] == '\\' && i+1 < len(m) {
+			i++
+		}
+		b.WriteByte(m[i])
+	}
+	return b.String()
+}
+
 // extractInterpreter extracts the interpreter name from a shebang line
 func extractInterpreter(shebang string) string {
 	shebang = strings.TrimPrefix(shebang, "#!")
@@ -107,6 +231,19 @@ func extractInterpreter(shebang string) string {
 
 // DetectLanguage detects the programming language from a file path
 func DetectLanguage(path string) string {
+	return detectLanguage(path, detectFromShebang)
+}
+
+// DetectLanguageFromContent detects the language of in-memory file content,
+// reading the shebang from content instead of from disk
+func DetectLanguageFromContent(path string, content []byte) string {
+	return detectLanguage(path, func(string) string {
+		line, _, _ := bytes.Cut(content, []byte("\n"))
+		return languageFromShebang(string(line))
+	})
+}
+
+func detectLanguage(path string, shebang func(path string) string) string {
 	// check special filenames first
 	base := filepath.Base(path)
 	if lang, ok := filenameToLang[strings.ToLower(base)]; ok {
@@ -121,7 +258,7 @@ func DetectLanguage(path string) string {
 
 	// check shebang for files without extension
 	if ext == "" || filepath.Ext(path) == "" {
-		if lang := detectFromShebang(path); lang != "" {
+		if lang := shebang(path); lang != "" {
 			return lang
 		}
 	}
@@ -138,27 +275,30 @@ func detectFromShebang(path string) string {
 
 	scanner := bufio.NewScanner(f)
 	if scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "#!") {
-			shebang := strings.TrimPrefix(line, "#!")
-			shebang = strings.TrimSpace(shebang)
-
-			// handle /usr/bin/env
-			if strings.Contains(shebang, "env ") {
-				parts := strings.Fields(shebang)
-				if len(parts) >= 2 {
-					shebang = parts[len(parts)-1]
-				}
-			}
+		return languageFromShebang(scanner.Text())
+	}
+	return ""
+}
 
-			// extract interpreter name
-			shebang = filepath.Base(shebang)
-			if lang, ok := shebangToLang[shebang]; ok {
-				return lang
-			}
+// languageFromShebang maps a "#!" line to a language, or "" if it isn't one
+func languageFromShebang(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	shebang := strings.TrimPrefix(line, "#!")
+	shebang = strings.TrimSpace(shebang)
+
+	// handle /usr/bin/env
+	if strings.Contains(shebang, "env ") {
+		parts := strings.Fields(shebang)
+		if len(parts) >= 2 {
+			shebang = parts[len(parts)-1]
 		}
 	}
-	return ""
+
+	// extract interpreter name
+	shebang = filepath.Base(shebang)
+	return shebangToLang[shebang]
 }
 
 func extToLanguage(ext string) string {
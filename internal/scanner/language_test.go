@@ -1,6 +1,9 @@
 package scanner
 
-import "testing"
+import (
+	"maps"
+	"testing"
+)
 
 func TestDetectLanguage_Extensions(t *testing.T) {
 	tests := []struct {
@@ -73,3 +76,75 @@ func TestExtToLanguage(t *testing.T) {
 		t.Error("extToLanguage(xyz) should return unknown")
 	}
 }
+
+// restoreLanguageTables undoes RegisterLanguages at the end of a test
+func restoreLanguageTables(t *testing.T) {
+	t.Helper()
+	saved := []map[string]string{maps.Clone(extToLang), maps.Clone(filenameToLang)}
+	savedLangs := maps.Clone(languages)
+	savedStamp := userLanguagesStamp
+	t.Cleanup(func() {
+		extToLang, filenameToLang = saved[0], saved[1]
+		languages = savedLangs
+		userLanguagesStamp = savedStamp
+	})
+}
+
+func TestRegisterLanguages(t *testing.T) {
+	restoreLanguageTables(t)
+
+	err := RegisterLanguages([]LanguageConfig{
+		{Name: "Pkl", Extensions: []string{".pkl"}, LineComment: []string{"//"}, MultiLineComments: [][]string{{"/*", "*/"}}, Category: "data"},
+		{Name: "Rego", Extensions: []string{"rego"}, LineComment: []string{"#"}, Category: "infra"},
+		{Name: "python", Extensions: []string{"pyi2"}},
+		{Name: "Go Template", Extensions: []string{"tmpl"}, MultiLineComments: [][]string{{"{{/*", "*/}}"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"config/app.pkl", "Pkl"},
+		{"policy/authz.rego", "Rego"}, // taken over from Open Policy Agent
+		{"stubs/os.pyi2", "Python"},   // extends the built-in language
+		{"web/page.tmpl", "Go Template"},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.path); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if got := GetLanguageCategory("Pkl"); got != CategoryData {
+		t.Errorf("Pkl category = %q, want %q", got, CategoryData)
+	}
+	if got := GetLanguageCategory("Python"); got != CategoryPrimary {
+		t.Errorf("extended Python category = %q, want %q", got, CategoryPrimary)
+	}
+	if !isKnownSourceExtension(".pkl") {
+		t.Error("user-defined extension .pkl is skipped in quick mode")
+	}
+
+	lexer := newLineLexer("Go Template")
+	if kind := lexer.classify([]byte("{{/* header */}}")); kind != lineComment {
+		t.Errorf("template comment classified as %v, want comment", kind)
+	}
+}
+
+func TestRegisterLanguages_Invalid(t *testing.T) {
+	restoreLanguageTables(t)
+
+	tests := []LanguageConfig{
+		{Name: "", Extensions: []string{"x"}},
+		{Name: "Bad", MultiLineComments: [][]string{{"/*"}}},
+		{Name: "Bad", Category: "nonsense"},
+	}
+	for _, def := range tests {
+		if err := RegisterLanguages([]LanguageConfig{def}); err == nil {
+			t.Errorf("RegisterLanguages(%+v) = nil, want error", def)
+		}
+	}
+}
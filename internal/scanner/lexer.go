@@ -0,0 +1,330 @@
+package scanner
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// lineKind classifies a single physical line
+type lineKind int
+
+const (
+	lineBlank lineKind = iota
+	lineCode
+	lineComment
+	lineDocstring // docstring or doc comment; also counted as a comment
+)
+
+// quotePair is a string literal delimiter pair
+type quotePair struct {
+	open     string
+	close    string
+	verbatim bool // raw string: backslash escapes are not processed
+	doc      bool // docstring when it opens a statement (Python """, Elixir @doc """)
+}
+
+// commentPair is a block comment delimiter pair
+type commentPair struct {
+	open   string
+	close  string
+	nested bool // an inner opener increases depth: /* /* */ */
+	doc    bool // documentation comment such as /** Javadoc */
+}
+
+// lineLexer is a per-language state machine that classifies lines as code,
+// comment or blank. It tracks string literals across lines so that comment
+// markers inside strings (e.g. "http://x/*") are not treated as comments.
+type lineLexer struct {
+	lineComments    []string
+	docLineComments []string // e.g. /// and //! for languages with // comments
+	blocks          []commentPair
+	quotes          []quotePair
+	charLiterals    bool      // recognize 'x' char literals (languages where ' is not a quote)
+	starts          [256]bool // first bytes of any token, to skip plain code quickly
+	plain           bool      // no comment or string syntax: every non-blank line is code
+
+	// state carried across lines
+	block int  // index into blocks of the open block comment, -1 if none
+	depth int  // nesting depth of the open block comment
+	quote int  // index into quotes of the open string literal, -1 if none
+	inDoc bool // the open string literal is a docstring
+}
+
+// newLineLexer builds a lexer from the language's comment and quote syntax
+func newLineLexer(lang string) *lineLexer {
+	l := &lineLexer{block: -1, quote: -1}
+	l.lineComments, l.blocks = commentSyntax(lang)
+
+	var docBlocks []commentPair
+	l.docLineComments, docBlocks = docCommentSyntax(l.lineComments, l.blocks)
+	l.blocks = append(l.blocks, docBlocks...)
+
+	if cfg, ok := GetLanguageConfig(lang); ok && !cfg.Blank {
+		l.quotes = append(l.quotes, toQuotePairs(cfg.Quotes, false, false)...)
+		l.quotes = append(l.quotes, toQuotePairs(cfg.DocQuotes, false, true)...)
+		l.quotes = append(l.quotes, toQuotePairs(cfg.VerbatimQuotes, true, false)...)
+	}
+
+	hasSingleQuote := false
+	for _, q := range l.quotes {
+		if q.open == "'" {
+			hasSingleQuote = true
+		}
+	}
+	l.charLiterals = len(l.quotes) > 0 && !hasSingleQuote
+
+	toks := l.tokens()
+	for _, tok := range toks {
+		l.starts[tok[0]] = true
+	}
+	l.plain = len(toks) == 0
+	if l.charLiterals {
+		l.starts['\''] = true
+	}
+	return l
+}
+
+func toQuotePairs(pairs [][]string, verbatim, doc bool) []quotePair {
+	var result []quotePair
+	for _, p := range pairs {
+		if len(p) == 2 && p[0] != "" && p[1] != "" {
+			result = append(result, quotePair{open: p[0], close: p[1], verbatim: verbatim, doc: doc})
+		}
+	}
+	return result
+}
+
+// docCommentSyntax derives documentation comment markers from a language's
+// comment syntax: /** */ (Javadoc, JSDoc, KDoc, Doxygen) for /* */ languages,
+// and /// or //! (rustdoc, XML doc comments, Doxygen) for // languages
+func docCommentSyntax(lineComments []string, blocks []commentPair) ([]string, []commentPair) {
+	var docLines []string
+	for _, m := range lineComments {
+		if m == "//" {
+			docLines = append(docLines, "///", "//!")
+		}
+	}
+
+	var docBlocks []commentPair
+	for _, b := range blocks {
+		if b.open == "/*" {
+			docBlocks = append(docBlocks, commentPair{open: "/**", close: b.close, nested: b.nested, doc: true})
+		}
+	}
+	return docLines, docBlocks
+}
+
+// tokens returns every token that can open a comment or string
+func (l *lineLexer) tokens() []string {
+	toks := append([]string(nil), l.lineComments...)
+	toks = append(toks, l.docLineComments...)
+	for _, b := range l.blocks {
+		toks = append(toks, b.open)
+	}
+	for _, q := range l.quotes {
+		toks = append(toks, q.open)
+	}
+	return toks
+}
+
+// classify consumes one line and returns its kind, updating lexer state.
+// It works on the reader's buffer directly and does not allocate.
+func (l *lineLexer) classify(line []byte) lineKind {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return lineBlank
+	}
+	if l.plain {
+		return lineCode
+	}
+
+	hasCode, hasComment, hasDoc := false, false, false
+	for i := 0; i < len(trimmed); {
+		switch {
+		case l.block >= 0:
+			b := l.blocks[l.block]
+			if b.doc {
+				hasDoc = true
+			} else {
+				hasComment = true
+			}
+			// close is checked first so pairs with identical delimiters never nest
+			if hasPrefix(trimmed[i:], b.close) {
+				l.depth--
+				if l.depth == 0 {
+					l.block = -1
+				}
+				i += len(b.close)
+				continue
+			}
+			if b.nested && hasPrefix(trimmed[i:], b.open) {
+				l.depth++
+				i += len(b.open)
+				continue
+			}
+			i++
+
+		case l.quote >= 0:
+			if l.inDoc {
+				hasDoc = true
+			} else {
+				hasCode = true
+			}
+			q := l.quotes[l.quote]
+			if !q.verbatim && trimmed[i] == '\\' {
+				i += 2
+				continue
+			}
+			if hasPrefix(trimmed[i:], q.close) {
+				l.quote = -1
+				l.inDoc = false
+				i += len(q.close)
+				continue
+			}
+			i++
+
+		default:
+			c := trimmed[i]
+			if c == ' ' || c == '\t' {
+				i++
+				continue
+			}
+			if !l.starts[c] {
+				hasCode = true
+				i++
+				continue
+			}
+
+			rest := trimmed[i:]
+			switch kind, n := l.matchToken(rest); kind {
+			case tokenLineComment, tokenDocLineComment:
+				// rest of the line is a comment
+				if kind == tokenDocLineComment {
+					hasDoc = true
+				} else {
+					hasComment = true
+				}
+				return resolveLineKind(hasCode, hasComment, hasDoc)
+			case tokenBlockComment:
+				if l.blocks[l.block].doc {
+					hasDoc = true
+				} else {
+					hasComment = true
+				}
+				i += n
+			case tokenQuote:
+				// a doc quote is only a docstring when it opens the statement
+				if l.quotes[l.quote].doc && !hasCode {
+					l.inDoc = true
+					hasDoc = true
+				} else {
+					hasCode = true
+				}
+				i += n
+			default:
+				hasCode = true
+				if n := l.charLiteralLen(rest); n > 0 {
+					i += n
+				} else {
+					i++
+				}
+			}
+		}
+	}
+
+	return resolveLineKind(hasCode, hasComment, hasDoc)
+}
+
+// resolveLineKind picks a line's kind: any code makes it code, and
+// documentation outranks plain comments
+func resolveLineKind(hasCode, hasComment, hasDoc bool) lineKind {
+	switch {
+	case hasCode:
+		return lineCode
+	case hasDoc:
+		return lineDocstring
+	case hasComment:
+		return lineComment
+	default:
+		return lineBlank
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenNone tokenKind = iota
+	tokenLineComment
+	tokenDocLineComment
+	tokenBlockComment
+	tokenQuote
+)
+
+// matchToken finds the longest comment or string opener at the start of s.
+// Longest match wins so that e.g. Lua's "--[[" beats "--" and Python's `"""`
+// beats `"`. On a block or quote match the lexer enters that state.
+func (l *lineLexer) matchToken(s []byte) (tokenKind, int) {
+	kind, best, idx := tokenNone, 0, -1
+
+	for _, m := range l.lineComments {
+		if len(m) > best && hasPrefix(s, m) {
+			kind, best = tokenLineComment, len(m)
+		}
+	}
+	for _, m := range l.docLineComments {
+		if len(m) > best && hasPrefix(s, m) {
+			kind, best = tokenDocLineComment, len(m)
+		}
+	}
+	for i, b := range l.blocks {
+		if len(b.open) <= best || !hasPrefix(s, b.open) {
+			continue
+		}
+		if b.doc && hasPrefix(s[len(b.open)-1:], b.close) {
+			continue // empty comment such as /**/, not a doc comment
+		}
+		kind, best, idx = tokenBlockComment, len(b.open), i
+	}
+	for i, q := range l.quotes {
+		if len(q.open) > best && hasPrefix(s, q.open) {
+			kind, best, idx = tokenQuote, len(q.open), i
+		}
+	}
+
+	switch kind {
+	case tokenBlockComment:
+		l.block, l.depth = idx, 1
+	case tokenQuote:
+		l.quote = idx
+	}
+	return kind, best
+}
+
+// charLiteralLen returns the length of a char literal such as 'a', '"' or
+// '\n' at the start of s, or 0. A lone ' (e.g. a Rust lifetime) is not a
+// literal and is left as plain code.
+func (l *lineLexer) charLiteralLen(s []byte) int {
+	if !l.charLiterals || len(s) < 3 || s[0] != '\'' {
+		return 0
+	}
+	if s[1] == '\\' {
+		// escaped char: '\n', '\'', '\x41', '\u{1F600}'
+		for j := 3; j < len(s) && j < 12; j++ {
+			if s[j] == '\'' {
+				return j + 1
+			}
+		}
+		return 0
+	}
+	_, size := utf8.DecodeRune(s[1:])
+	if 1+size < len(s) && s[1+size] == '\'' {
+		return size + 2
+	}
+	return 0
+}
+
+// hasPrefix is strings.HasPrefix for a byte slice and a string token; the
+// comparison converts without allocating
+func hasPrefix(s []byte, prefix string) bool {
+	return len(s) >= len(prefix) && string(s[:len(prefix)]) == prefix
+}
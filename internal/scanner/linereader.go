@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"bytes"
+	"io"
+)
+
+// lineReader splits text into lines like bufio.Scanner with ScanLines, but
+// has no maximum line length: a line longer than the buffer is assembled
+// across reads instead of aborting the scan (minified bundles and generated
+// data files routinely have multi-megabyte lines)
+type lineReader struct {
+	r          io.Reader
+	buf        []byte
+	start, end int    // unconsumed bytes in buf
+	long       []byte // a line that outgrew buf
+	line       []byte
+	eof        bool
+	err        error
+}
+
+func newLineReader(r io.Reader, buf []byte) *lineReader {
+	return &lineReader{r: r, buf: buf}
+}
+
+// newByteLineReader splits in-memory text; lines are slices of text itself
+func newByteLineReader(text []byte) *lineReader {
+	return &lineReader{buf: text, end: len(text), eof: true}
+}
+
+// Scan advances to the next line, which is then available through Bytes
+func (l *lineReader) Scan() bool {
+	l.long = l.long[:0]
+	for {
+		if i := bytes.IndexByte(l.buf[l.start:l.end], '\n'); i >= 0 {
+			l.setLine(l.buf[l.start : l.start+i])
+			l.start += i + 1
+			return true
+		}
+
+		if l.eof || l.err != nil {
+			// final line without a trailing newline
+			rest := l.buf[l.start:l.end]
+			l.start = l.end
+			if len(rest) == 0 && len(l.long) == 0 {
+				return false
+			}
+			l.setLine(rest)
+			return true
+		}
+
+		// make room for the next read: shift the partial line to the front,
+		// or set it aside when it fills the whole buffer
+		if l.start > 0 {
+			l.end = copy(l.buf, l.buf[l.start:l.end])
+			l.start = 0
+		} else if l.end == len(l.buf) {
+			l.long = append(l.long, l.buf[:l.end]...)
+			l.end = 0
+		}
+
+		n, err := l.r.Read(l.buf[l.end:])
+		l.end += n
+		if err == io.EOF {
+			l.eof = true
+		} else if err != nil {
+			l.err = err
+		}
+	}
+}
+
+func (l *lineReader) setLine(tail []byte) {
+	line := tail
+	if len(l.long) > 0 {
+		l.long = append(l.long, tail...)
+		line = l.long
+	}
+	// drop a trailing \r from CRLF line endings
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	l.line = line
+}
+
+// Bytes returns the current line, valid until the next call to Scan
+func (l *lineReader) Bytes() []byte {
+	return l.line
+}
+
+// Text returns the current line as a string
+func (l *lineReader) Text() string {
+	return string(l.line)
+}
+
+// Err returns the first non-EOF read error
+func (l *lineReader) Err() error {
+	return l.err
+}
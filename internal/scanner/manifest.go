@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// maxManifestSize bounds the manifests read; real ones are far smaller
+const maxManifestSize = 1 << 20
+
+// manifestSet collects the contents of the files named in
+// Options.Manifests (go.mod, package.json, ...) as a scan finds them,
+// whether or not they are counted
+type manifestSet struct {
+	names map[string]bool
+
+	mu    sync.Mutex
+	files map[string][]byte // by root-relative path
+}
+
+func newManifestSet(names []string) *manifestSet {
+	if len(names) == 0 {
+		return nil
+	}
+	m := &manifestSet{names: make(map[string]bool, len(names)), files: make(map[string][]byte)}
+	for _, name := range names {
+		m.names[name] = true
+	}
+	return m
+}
+
+// wants reports whether a file name is a manifest to collect
+func (m *manifestSet) wants(name string) bool {
+	return m != nil && m.names[name]
+}
+
+func (m *manifestSet) add(relPath string, content []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[filepath.ToSlash(relPath)] = content
+}
+
+// readFile collects a manifest from disk; unreadable and oversized files
+// are skipped
+func (m *manifestSet) readFile(path, relPath string) {
+	if info, err := os.Stat(path); err != nil || info.Size() > maxManifestSize {
+		return
+	}
+	if content, err := os.ReadFile(path); err == nil {
+		m.add(relPath, content)
+	}
+}
+
+// all returns the collected manifests by slash-separated root-relative path
+func (m *manifestSet) all() map[string][]byte {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files
+}
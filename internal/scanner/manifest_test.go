@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestScanner_Manifests(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                        "module example.com/app\n",
+		"main.go":                       "package main\n",
+		"web/package.json":              `{"name": "web"}`,
+		"node_modules/dep/package.json": `{"name": "dep"}`,
+		"examples/demo/go.mod":          "module example.com/demo\n",
+		"third_party/lib/.git/HEAD":     "ref: refs/heads/main\n",
+		"third_party/lib/go.mod":        "module example.com/lib\n",
+		"third_party/lib/lib.go":        "package lib\n",
+	})
+
+	opts := Options{Manifests: []string{"go.mod", "package.json"}, Exclude: []string{"examples/**"}}
+	manifests := func(opts Options) []string {
+		t.Helper()
+		s, err := NewScanner(root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, errs := s.Scan(context.Background())
+		for range results {
+		}
+		for err := range errs {
+			t.Errorf("scan error: %v", err)
+		}
+		return slices.Sorted(maps.Keys(s.Manifests()))
+	}
+
+	// go.mod is collected although it is not counted; skipped, excluded and
+	// vendored nested repository directories are not searched
+	if got, want := manifests(opts), []string{"go.mod", "web/package.json"}; !slices.Equal(got, want) {
+		t.Errorf("Manifests = %q, want %q", got, want)
+	}
+
+	opts.Submodules = SubmodulesRecurse
+	if got, want := manifests(opts), []string{"go.mod", "third_party/lib/go.mod", "web/package.json"}; !slices.Equal(got, want) {
+		t.Errorf("Manifests with recursed submodules = %q, want %q", got, want)
+	}
+}
+
+func TestRevScanner_Manifests(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":               "module example.com/app\n",
+		"main.go":              "package main\n",
+		"examples/demo/go.mod": "module example.com/demo\n",
+	})
+	gitCmd(t, root, "init", "-q")
+	gitCmd(t, root, "add", "-A")
+	gitCmd(t, root, "commit", "-q", "-m", "initial")
+	writeFiles(t, root, map[string]string{"go.mod": "module example.com/changed\n"})
+
+	s, err := NewRevScanner(root, "HEAD", Options{Manifests: []string{"go.mod"}, Exclude: []string{"examples/**"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, errs := s.Scan(context.Background())
+	for range results {
+	}
+	for err := range errs {
+		t.Errorf("scan error: %v", err)
+	}
+
+	manifests := s.Manifests()
+	if len(manifests) != 1 || string(manifests["go.mod"]) != "module example.com/app\n" {
+		t.Errorf("Manifests = %q, want the committed go.mod only", manifests)
+	}
+}
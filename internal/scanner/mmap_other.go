@@ -0,0 +1,13 @@
+//go:build !unix
+
+package scanner
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is unsupported here; large files are streamed like small ones
+func mapFile(f *os.File, size int64) (data []byte, unmap func(), err error) {
+	return nil, nil, errors.ErrUnsupported
+}
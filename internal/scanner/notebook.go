@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// notebookLanguage is the display name of Jupyter notebooks in languages.json
+const notebookLanguage = "Jupyter Notebooks"
+
+// notebook is the subset of the .ipynb format needed for counting.
+// nbformat 4 keeps cells at the top level; nbformat 3 nests them in worksheets.
+type notebook struct {
+	Cells      []notebookCell `json:"cells"`
+	Worksheets []struct {
+		Cells []notebookCell `json:"cells"`
+	} `json:"worksheets"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+		Language string `json:"language"` // nbformat 3
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType string         `json:"cell_type"`
+	Source   notebookSource `json:"source"`
+	Input    notebookSource `json:"input"` // nbformat 3 code cells
+}
+
+// notebookSource is cell source stored either as one string or as a list of lines
+type notebookSource string
+
+func (s *notebookSource) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = notebookSource(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*s = notebookSource(text)
+	return nil
+}
+
+// countNotebook counts a Jupyter notebook by cell rather than as JSON: code
+// cells are counted in the kernel's language and reported as embedded code,
+// markdown cells count as documentation, and outputs are ignored. Content
+// that isn't a valid notebook is counted as plain JSON.
+func countNotebook(content []byte) (model.LineMetrics, map[string]model.LineMetrics) {
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		return countCodeBlockLines(strings.Split(strings.TrimSuffix(string(content), "\n"), "\n"), "JSON"), nil
+	}
+
+	cells := nb.Cells
+	for _, ws := range nb.Worksheets {
+		cells = append(cells, ws.Cells...)
+	}
+	kernelLang := notebookKernelLanguage(&nb)
+
+	var metrics model.LineMetrics
+	embedded := make(map[string]model.LineMetrics)
+
+	for _, cell := range cells {
+		switch cell.CellType {
+		case "code":
+			source := cell.Source
+			if source == "" {
+				source = cell.Input
+			}
+			lines := splitCellLines(string(source))
+			if len(lines) == 0 {
+				continue
+			}
+			lang := cellLanguage(lines[0], kernelLang)
+			cellMetrics := countCodeBlockLines(lines, lang)
+			metrics.Add(cellMetrics)
+
+			existing := embedded[lang]
+			existing.Add(cellMetrics)
+			embedded[lang] = existing
+
+		case "markdown":
+			for _, line := range splitCellLines(string(cell.Source)) {
+				metrics.Total++
+				if strings.TrimSpace(line) == "" {
+					metrics.Blanks++
+				} else {
+					metrics.Comments++
+					metrics.Docstrings++
+				}
+			}
+		}
+		// raw cells are neither code nor docs
+	}
+
+	if len(embedded) == 0 {
+		return metrics, nil
+	}
+	return metrics, embedded
+}
+
+// notebookKernelLanguage returns the notebook's language, defaulting to Python
+func notebookKernelLanguage(nb *notebook) string {
+	for _, name := range []string{nb.Metadata.Kernelspec.Language, nb.Metadata.LanguageInfo.Name, nb.Metadata.Language} {
+		if name != "" {
+			return normalizeCodeBlockLang(name)
+		}
+	}
+	return "Python"
+}
+
+// cellLanguage honors cell magics that switch a cell's language, such as
+// %%bash or %%sql; other magics (%%time) keep the kernel language
+func cellLanguage(firstLine, kernelLang string) string {
+	magic, ok := strings.CutPrefix(strings.TrimSpace(firstLine), "%%")
+	if !ok {
+		return kernelLang
+	}
+	name, _, _ := strings.Cut(magic, " ")
+	if lang := normalizeCodeBlockLang(name); lang != "" {
+		if _, known := GetLanguageConfig(lang); known {
+			return lang
+		}
+	}
+	return kernelLang
+}
+
+func splitCellLines(source string) []string {
+	source = strings.TrimSuffix(source, "\n")
+	if source == "" {
+		return nil
+	}
+	return strings.Split(source, "\n")
+}
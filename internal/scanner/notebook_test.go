@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+const testNotebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": ["# Analysis\n", "\n", "Load the data."]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "outputs": [{"output_type": "stream", "name": "stdout", "text": ["a\n", "b\n", "c\n"]}],
+   "source": ["import pandas as pd\n", "\n", "# read it\n", "df = pd.read_csv('x.csv')"]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 2,
+   "metadata": {},
+   "outputs": [],
+   "source": "%%bash\nls -la"
+  },
+  {
+   "cell_type": "raw",
+   "metadata": {},
+   "source": ["ignored\n"]
+  }
+ ],
+ "metadata": {
+  "kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"}
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestCountLinesWithEmbedded_Notebook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analysis.ipynb")
+	if err := os.WriteFile(path, []byte(testNotebook), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lines, embedded, err := CountLinesWithEmbedded(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// markdown: 2 doc lines + 1 blank; python: 2 code, 1 comment, 1 blank; bash: 2 code
+	want := model.LineMetrics{Total: 9, Blanks: 2, Comments: 3, Docstrings: 2, Code: 4}
+	if lines != want {
+		t.Errorf("lines = %+v, want %+v", lines, want)
+	}
+
+	wantEmbedded := map[string]model.LineMetrics{
+		"Python": {Total: 4, Blanks: 1, Comments: 1, Code: 2},
+		"BASH":   {Total: 2, Code: 2},
+	}
+	if len(embedded) != len(wantEmbedded) {
+		t.Fatalf("embedded = %+v, want %+v", embedded, wantEmbedded)
+	}
+	for lang, w := range wantEmbedded {
+		if embedded[lang] != w {
+			t.Errorf("embedded[%s] = %+v, want %+v", lang, embedded[lang], w)
+		}
+	}
+}
+
+func TestCountContent_NotebookKernelLanguage(t *testing.T) {
+	content := `{"cells": [{"cell_type": "code", "source": ["x <- 1\n", "# note"]}],
+	 "metadata": {"language_info": {"name": "R"}}}`
+
+	_, embedded := CountContent("stats.ipynb", []byte(content))
+	if got := embedded["R"]; got != (model.LineMetrics{Total: 2, Comments: 1, Code: 1}) {
+		t.Errorf("embedded[R] = %+v, want 2 lines (1 code, 1 comment)", got)
+	}
+}
+
+func TestCountContent_InvalidNotebook(t *testing.T) {
+	lines, embedded := CountContent("broken.ipynb", []byte("{\n  \"cells\": [\n"))
+	if lines.Total != 2 || embedded != nil {
+		t.Errorf("invalid notebook = %+v, %v; want 2 lines counted as JSON", lines, embedded)
+	}
+}
@@ -0,0 +1,194 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/modern-tooling/aloc/internal/git"
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// RevScanner scans the tree of a git revision instead of the working tree.
+// Blobs are streamed from a single `git cat-file --batch` process, so nothing
+// is checked out; uncommitted changes and untracked files are not seen.
+type RevScanner struct {
+	walker *Walker // filters and worker count; the walk itself is unused
+	rev    string
+	header bool // keep each file's leading bytes for header probing
+}
+
+// NewRevScanner creates a scanner for rev (a commit, tag or branch) of the
+// repository containing root. Only files below root are scanned.
+func NewRevScanner(root, rev string, opts Options) (*RevScanner, error) {
+	walker, err := NewWalker(root, walkOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := git.ResolveRev(walker.root, rev); err != nil {
+		return nil, err
+	}
+	return &RevScanner{walker: walker, rev: rev, header: opts.HeaderProbe}, nil
+}
+
+type revBlob struct {
+	entry   git.TreeEntry
+	content []byte
+}
+
+func (s *RevScanner) Scan(ctx context.Context) (<-chan *model.RawFile, <-chan error) {
+	results := make(chan *model.RawFile, 8192)
+	errs := make(chan error, 256)
+	blobs := make(chan revBlob, 256)
+
+	entries, err := git.ListTree(s.walker.root, s.rev)
+	if err != nil {
+		errs <- err
+		close(results)
+		close(errs)
+		return results, errs
+	}
+	neighbors := treeExtensions(entries)
+
+	// cat-file answers requests in order, so a single reader feeds the workers
+	// (and reads the tree's .gitattributes before any blob is sent)
+	var attributes *GitAttributes
+	go func() {
+		defer close(blobs)
+
+		reader, err := git.NewBlobReader(s.walker.root)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer reader.Close()
+
+		attrFiles := make(map[string][]byte)
+		for _, entry := range entries {
+			if filepath.Base(entry.Path) != ".gitattributes" {
+				continue
+			}
+			content, err := reader.Read(entry.Object)
+			if err != nil {
+				errs <- err
+				return
+			}
+			attrFiles[entry.Path] = content
+		}
+		attributes = treeAttributes(s.walker.root, attrFiles)
+
+		// manifests are collected whether or not they are counted; a tree
+		// lists no submodule files
+		for _, entry := range entries {
+			if !s.walker.manifests.wants(filepath.Base(entry.Path)) || entry.Size > maxManifestSize ||
+				!s.walker.visitsTreePath(filepath.FromSlash(entry.Path)) {
+				continue
+			}
+			content, err := reader.Read(entry.Object)
+			if err != nil {
+				errs <- err
+				return
+			}
+			s.walker.manifests.add(entry.Path, content)
+		}
+
+		for _, entry := range entries {
+			if !s.walker.includesTreePath(filepath.FromSlash(entry.Path)) {
+				continue
+			}
+			content, err := reader.Read(entry.Object)
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case blobs <- revBlob{entry: entry, content: content}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.walker.numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for blob := range blobs {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				path := filepath.FromSlash(blob.entry.Path)
+				text, decodeErr := decodeContent(blob.content)
+				if decodeErr != nil {
+					decodeErr.Path = path
+					errs <- decodeErr // counted anyway
+				}
+
+				linguist := attributes.Match(path)
+				lang := linguist.Language
+				var fromContent bool
+				if lang == "" {
+					lang = DetectLanguageFromContent(path, text)
+					var disambiguated string
+					if disambiguated, fromContent = Disambiguate(path, text, neighbors); fromContent {
+						lang = disambiguated
+					}
+				}
+				c := countContentAs(text, lang)
+
+				var header []byte
+				if s.header {
+					header = bytes.Clone(text[:min(len(text), headerSize)])
+				}
+
+				results <- &model.RawFile{
+					Path:                path,
+					Bytes:               blob.entry.Size,
+					LOC:                 c.lines.Code,
+					Lines:               c.lines,
+					LanguageHint:        lang,
+					LanguageFromContent: fromContent,
+					Embedded:            c.embedded,
+					Shape:               c.shape,
+					TestMarker:          c.marker,
+					Header:              header,
+					Linguist:            linguist,
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+		close(errs)
+	}()
+
+	return results, errs
+}
+
+// Manifests returns the contents of the manifests in the tree, by
+// slash-separated root-relative path. Call it once the scan is done.
+func (s *RevScanner) Manifests() map[string][]byte {
+	return s.walker.manifests.all()
+}
+
+// treeExtensions indexes the extensions in each directory of a tree, for
+// neighbor-based disambiguation
+func treeExtensions(entries []git.TreeEntry) func(dir string) map[string]bool {
+	dirs := make(map[string]map[string]bool)
+	for _, e := range entries {
+		dir := filepath.Dir(filepath.FromSlash(e.Path))
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string]bool)
+		}
+		dirs[dir][strings.ToLower(filepath.Ext(e.Path))] = true
+	}
+	return func(dir string) map[string]bool { return dirs[dir] }
+}
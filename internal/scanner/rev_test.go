@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func scanRev(t *testing.T, root, rev string, opts Options) map[string]*model.RawFile {
+	t.Helper()
+	s, err := NewRevScanner(root, rev, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, errs := s.Scan(context.Background())
+	files := make(map[string]*model.RawFile)
+	for f := range results {
+		files[filepath.ToSlash(f.Path)] = f
+	}
+	for err := range errs {
+		t.Errorf("scan error: %v", err)
+	}
+	return files
+}
+
+func TestRevScanner_ReadsCommittedTree(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":                "package main\n\n// entry point\nfunc main() {}\n",
+		"run":                    "#!/usr/bin/env python3\nprint('hi')\n",
+		"node_modules/dep/x.js":  "module.exports = 1\n",
+		"internal/util/strs.go":  "package util\n",
+		"internal/util/bin.data": "\x00\x01",
+	})
+	gitCmd(t, root, "init", "-q")
+	gitCmd(t, root, "add", "-A")
+	gitCmd(t, root, "commit", "-q", "-m", "initial")
+
+	// working tree changes must not show up in the revision
+	writeFiles(t, root, map[string]string{
+		"main.go":  "package main\n",
+		"extra.go": "package main\n",
+	})
+	if err := os.Remove(filepath.Join(root, "internal/util/strs.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	files := scanRev(t, root, "HEAD", Options{DeepMode: true, HeaderProbe: true})
+
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	assertPaths(t, paths, []string{"internal/util/bin.data", "internal/util/strs.go", "main.go", "run"})
+
+	main := files["main.go"]
+	want := model.LineMetrics{Total: 4, Blanks: 1, Comments: 1, Code: 2}
+	if main.Lines != want {
+		t.Errorf("main.go lines = %+v, want %+v", main.Lines, want)
+	}
+	if main.LanguageHint != "Go" {
+		t.Errorf("main.go language = %q, want Go", main.LanguageHint)
+	}
+	if string(main.Header) != "package main\n\n// entry point\nfunc main() {}\n" {
+		t.Errorf("main.go header = %q", main.Header)
+	}
+	if lang := files["run"].LanguageHint; lang != "Python" {
+		t.Errorf("run language = %q, want Python", lang)
+	}
+	if lines := files["internal/util/bin.data"].Lines; lines != (model.LineMetrics{}) {
+		t.Errorf("binary lines = %+v, want zero", lines)
+	}
+}
+
+func TestRevScanner_Subdirectory(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":          "package main\n",
+		"api/handler.go":   "package api\n",
+		"api/v1/routes.go": "package v1\n",
+	})
+	gitCmd(t, root, "init", "-q")
+	gitCmd(t, root, "add", "-A")
+	gitCmd(t, root, "commit", "-q", "-m", "initial")
+
+	files := scanRev(t, filepath.Join(root, "api"), "HEAD", Options{})
+
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	assertPaths(t, paths, []string{"handler.go", "v1/routes.go"})
+}
+
+func TestNewRevScanner_UnknownRev(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	gitCmd(t, root, "init", "-q")
+
+	if _, err := NewRevScanner(root, "no-such-branch", Options{}); err == nil {
+		t.Error("NewRevScanner with unknown revision: want error")
+	}
+}
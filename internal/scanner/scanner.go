@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"cmp"
 	"context"
 	"os"
 	"path/filepath"
@@ -10,25 +11,48 @@ import (
 )
 
 type Scanner struct {
-	walker *Walker
+	walker    *Walker
+	cache     *Cache
+	header    bool
+	neighbors dirExtensions
 }
 
 type Options struct {
-	NumWorkers int
-	Exclude    []string
-	DeepMode   bool
+	NumWorkers        int
+	Exclude           []string
+	DeepMode          bool
+	IncludeExtensions []string
+	SkipExtensions    []string
+	Submodules        SubmoduleMode // how nested repositories are scanned (default vendor)
+	HeaderProbe       bool          // keep each file's leading bytes in RawFile.Header
+	Cache             *Cache        // optional; unchanged files are served from it
+	Manifests         []string      // file names (e.g. go.mod) collected whether or not they are counted
 }
 
 func NewScanner(root string, opts Options) (*Scanner, error) {
-	walker, err := NewWalker(root, WalkOptions{
-		NumWorkers: opts.NumWorkers,
-		Exclude:    opts.Exclude,
-		DeepMode:   opts.DeepMode,
-	})
+	walker, err := NewWalker(root, walkOptions(opts))
 	if err != nil {
 		return nil, err
 	}
-	return &Scanner{walker: walker}, nil
+	return &Scanner{walker: walker, cache: opts.Cache, header: opts.HeaderProbe}, nil
+}
+
+func walkOptions(opts Options) WalkOptions {
+	return WalkOptions{
+		NumWorkers:        opts.NumWorkers,
+		Exclude:           opts.Exclude,
+		DeepMode:          opts.DeepMode,
+		IncludeExtensions: opts.IncludeExtensions,
+		SkipExtensions:    opts.SkipExtensions,
+		Submodules:        opts.Submodules,
+		Manifests:         opts.Manifests,
+	}
+}
+
+// Manifests returns the contents of the manifests the last scan found, by
+// slash-separated root-relative path. Call it once the scan is done.
+func (s *Scanner) Manifests() map[string][]byte {
+	return s.walker.manifests.all()
 }
 
 func (s *Scanner) Scan(ctx context.Context) (<-chan *model.RawFile, <-chan error) {
@@ -56,42 +80,44 @@ func (s *Scanner) Scan(ctx context.Context) (<-chan *model.RawFile, <-chan error
 					continue
 				}
 
-				lang := DetectLanguage(path)
-
-				// Use embedded-aware counting for Markdown/MDX
-				var lines model.LineMetrics
-				var embedded map[string]model.LineMetrics
-				var countErr error
-
-				if lang == "Markdown" || lang == "MDX" {
-					lines, embedded, countErr = CountLinesWithEmbedded(path)
-				} else {
-					lines, countErr = CountLines(path)
+				// use relative path for inference rules to work correctly
+				relPath, relErr := filepath.Rel(s.walker.root, path)
+				if relErr != nil {
+					relPath = path
 				}
+
+				linguist := s.walker.attributes.Match(relPath)
+				r, countErr := s.count(path, relPath, info, linguist.Language)
 				if countErr != nil {
 					errs <- countErr
 					continue
 				}
-
-				// use relative path for inference rules to work correctly
-				relPath, relErr := filepath.Rel(s.walker.root, path)
-				if relErr != nil {
-					relPath = path
+				if r.decodeErr != nil {
+					r.decodeErr.Path = relPath
+					errs <- r.decodeErr // counted anyway
 				}
 
 				results <- &model.RawFile{
-					Path:         relPath,
-					Bytes:        info.Size(),
-					LOC:          lines.Code,
-					Lines:        lines,
-					LanguageHint: lang,
-					Embedded:     embedded,
+					Path:                relPath,
+					Bytes:               info.Size(),
+					LOC:                 r.lines.Code,
+					Lines:               r.lines,
+					LanguageHint:        r.lang,
+					LanguageFromContent: r.fromContent,
+					Embedded:            r.embedded,
+					Shape:               r.shape,
+					TestMarker:          r.marker,
+					Header:              r.header,
+					Submodule:           s.walker.submoduleOf(relPath),
+					Linguist:            linguist,
 				}
 			}
 		}()
 	}
 
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		for err := range walkErrs {
 			errs <- err
 		}
@@ -105,3 +131,83 @@ func (s *Scanner) Scan(ctx context.Context) (<-chan *model.RawFile, <-chan error
 
 	return results, errs
 }
+
+// count detects a file's language, unless .gitattributes sets one, and
+// counts its lines, consulting the cache first
+func (s *Scanner) count(path, relPath string, info os.FileInfo, language string) (scanResult, error) {
+	// extensions shared by several languages are resolved from content and
+	// neighbors; the neighbors aren't covered by a cache entry's size and
+	// mtime, so this happens before the cache is consulted
+	var disambiguated string
+	ambiguous := language == "" && isAmbiguousPath(path)
+	if ambiguous {
+		head, err := readHead(path, disambiguateBytes)
+		if err != nil {
+			return scanResult{}, err
+		}
+		head, _ = decodeContent(head)
+		disambiguated, _ = Disambiguate(path, head, s.neighbors.lookup)
+	}
+
+	if s.cache != nil {
+		want := language
+		if ambiguous {
+			want = cmp.Or(disambiguated, DetectLanguage(path))
+		}
+		// an entry counted under another language attribute, or resolved
+		// to another language, is stale
+		if e, ok := s.cache.lookup(relPath, info); ok && e.LanguageFromAttributes == (language != "") && (want == "" || e.Language == want) {
+			r := scanResult{
+				lang:        e.Language,
+				fromContent: e.LanguageFromContent,
+				fileCounts:  fileCounts{lines: e.Lines, embedded: e.Embedded, shape: e.Shape, marker: e.TestMarker},
+			}
+			// the header isn't cached, so it is read again
+			if s.header {
+				head, err := readHead(path, 2*headerSize)
+				if err != nil {
+					return scanResult{}, err
+				}
+				if _, binary := sniffEncoding(head); !binary {
+					r.header = captureHeader(head)
+				}
+			}
+			return r, nil
+		}
+	}
+
+	r := scanResult{lang: DetectLanguage(path)}
+	if language != "" {
+		r.lang = language
+	} else if disambiguated != "" {
+		r.lang, r.fromContent = disambiguated, true
+	}
+
+	// embedded-aware counting for Markdown/MDX, notebooks and components
+	var err error
+	r.fileCounts, err = countFile(path, r.lang, s.header)
+	if err != nil {
+		return scanResult{}, err
+	}
+
+	// files with decoding problems aren't cached so the warning repeats
+	if s.cache != nil && r.decodeErr == nil {
+		s.cache.store(relPath, info, cacheEntry{
+			Lines:                  r.lines,
+			Language:               r.lang,
+			LanguageFromContent:    r.fromContent,
+			LanguageFromAttributes: language != "",
+			Embedded:               r.embedded,
+			Shape:                  r.shape,
+			TestMarker:             r.marker,
+		})
+	}
+	return r, nil
+}
+
+// scanResult is the language and line counts of one file
+type scanResult struct {
+	lang        string
+	fromContent bool // language disambiguated from content
+	fileCounts
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestScanner_HeaderProbe(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	generated := "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage pb\n"
+	writeFiles(t, root, map[string]string{
+		"pb/user.pb.go": generated,
+		"Model.cs":      utf16le("// <auto-generated/>\nclass Model {}\n", true),
+		"big.go":        generated + strings.Repeat("var x = 1\n", 200_000), // mapped, not streamed
+	})
+
+	if files := scanWith(t, root, Options{}); files["pb/user.pb.go"].Header != nil {
+		t.Errorf("header = %q without probing, want nil", files["pb/user.pb.go"].Header)
+	}
+
+	mtime := time.Now().Add(-time.Hour)
+	for _, name := range []string{"pb/user.pb.go", "Model.cs", "big.go"} {
+		setMtime(t, filepath.Join(root, name), mtime)
+	}
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+	cache := OpenCache(cachePath, "test")
+	first := scanWith(t, root, Options{HeaderProbe: true, Cache: cache})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// cache hits skip counting but still read the header
+	second := scanWith(t, root, Options{HeaderProbe: true, Cache: OpenCache(cachePath, "test")})
+
+	for i, files := range []map[string]*model.RawFile{first, second} {
+		if got := string(files["pb/user.pb.go"].Header); got != generated {
+			t.Errorf("scan %d: user.pb.go header = %q, want whole file", i, got)
+		}
+		if got := string(files["Model.cs"].Header); !strings.HasPrefix(got, "// <auto-generated/>\n") {
+			t.Errorf("scan %d: Model.cs header = %q, want decoded UTF-8", i, got)
+		}
+		big := files["big.go"].Header
+		if len(big) != headerSize || !strings.HasPrefix(string(big), generated) {
+			t.Errorf("scan %d: big.go header is %d bytes, want the first %d", i, len(big), headerSize)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SubmoduleMode controls how git submodules and nested checkouts are scanned
+type SubmoduleMode string
+
+const (
+	SubmodulesVendor  SubmoduleMode = "vendor"  // scanned and classified as vendored code (default)
+	SubmodulesSkip    SubmoduleMode = "skip"    // not scanned
+	SubmodulesRecurse SubmoduleMode = "recurse" // scanned and classified like the rest, with their own history
+)
+
+// ParseSubmoduleMode validates a --submodules / options.submodules value;
+// empty means the default
+func ParseSubmoduleMode(s string) (SubmoduleMode, error) {
+	switch mode := SubmoduleMode(strings.ToLower(s)); mode {
+	case "":
+		return SubmodulesVendor, nil
+	case SubmodulesVendor, SubmodulesSkip, SubmodulesRecurse:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid submodule mode %q (want vendor, skip or recurse)", s)
+}
+
+// readGitmodules returns the root-relative paths of the submodules declared
+// in root's .gitmodules. A missing file yields none.
+func readGitmodules(root string) map[string]bool {
+	f, err := os.Open(filepath.Join(root, ".gitmodules"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	paths := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.TrimSpace(key) != "path" {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if value != "" {
+			paths[filepath.Clean(filepath.FromSlash(value))] = true
+		}
+	}
+	return paths
+}
+
+// isNestedRepo reports whether a directory below the root is a submodule or
+// a separate checkout: declared in .gitmodules, or holding its own .git
+// (a directory for checkouts, a gitlink file for submodules)
+func (w *Walker) isNestedRepo(path, relPath string) bool {
+	if relPath == "." {
+		return false
+	}
+	if w.gitmodules[relPath] {
+		return true
+	}
+	_, err := os.Lstat(filepath.Join(path, ".git"))
+	return err == nil
+}
+
+// submoduleOf returns the root-relative path of the nested repository
+// containing a root-relative file path, or "" for files of the scanned repo
+func (w *Walker) submoduleOf(relPath string) string {
+	for dir := filepath.Dir(relPath); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if _, ok := w.nested.Load(dir); ok {
+			return dir
+		}
+	}
+	return ""
+}
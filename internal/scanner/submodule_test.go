@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScanner_Submodules(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitmodules":          "[submodule \"auth\"]\n\tpath = libs/auth\n\turl = ../auth.git\n",
+		"main.go":              "package main\n",
+		"libs/auth/.git":       "gitdir: ../../.git/modules/auth\n",
+		"libs/auth/auth.go":    "package auth\n",
+		"libs/auth/jwt/jwt.go": "package jwt\n",
+		"tools/lint/.git/HEAD": "ref: refs/heads/main\n",
+		"tools/lint/lint.go":   "package lint\n",
+	})
+
+	files := scanWith(t, root, Options{DeepMode: true})
+	want := map[string]string{
+		"main.go":              "",
+		"libs/auth/auth.go":    filepath.FromSlash("libs/auth"),
+		"libs/auth/jwt/jwt.go": filepath.FromSlash("libs/auth"),
+		"tools/lint/lint.go":   filepath.FromSlash("tools/lint"),
+		".gitmodules":          "",
+	}
+	if len(files) != len(want) {
+		t.Errorf("scanned %d files, want %d (the gitlink file is not source)", len(files), len(want))
+	}
+	for path, sub := range want {
+		f, ok := files[path]
+		if !ok {
+			t.Errorf("%s missing from scan", path)
+			continue
+		}
+		if f.Submodule != sub {
+			t.Errorf("%s Submodule = %q, want %q", path, f.Submodule, sub)
+		}
+	}
+
+	files = scanWith(t, root, Options{Submodules: SubmodulesSkip})
+	if len(files) != 1 || files["main.go"] == nil {
+		t.Errorf("skip mode scanned %d files, want only main.go", len(files))
+	}
+}
+
+func TestParseSubmoduleMode(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    SubmoduleMode
+		wantErr bool
+	}{
+		{"", SubmodulesVendor, false},
+		{"skip", SubmodulesSkip, false},
+		{"Recurse", SubmodulesRecurse, false},
+		{"ignore", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSubmoduleMode(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseSubmoduleMode(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
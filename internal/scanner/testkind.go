@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+// testKindMarker sets the kind of a test from a line of its source
+type testKindMarker struct {
+	languages []string
+	literal   string // every matching line contains it, a cheap prefilter
+	pattern   *regexp.Regexp
+	kind      model.TestKind
+}
+
+var (
+	goLanguages   = []string{"Go"}
+	pyLanguages   = []string{"Python"}
+	jvmLanguages  = []string{"Java", "Kotlin", "Scala", "Groovy"}
+	rustLanguages = []string{"Rust"}
+	jsLanguages   = []string{"JavaScript", "TypeScript", "JSX", "TSX"}
+)
+
+// goBuildTag matches a Go build constraint requiring a tag (not !tag)
+func goBuildTag(tag string, kind model.TestKind) testKindMarker {
+	return testKindMarker{goLanguages, tag, regexp.MustCompile(`^//\s*(go:build|\+build)\s(.*[\s(|&,])?` + tag + `\b`), kind}
+}
+
+// testKindMarkers are checked in order; the first that matches any line of
+// a file decides. Suite markers (build tags, pytest marks, JUnit tags) come
+// first, then the regular test functions that make a file with a stray
+// benchmark a unit test, then benchmarks and fuzz targets.
+var testKindMarkers = []testKindMarker{
+	// Suite markers
+	goBuildTag("e2e", model.TestE2E),
+	goBuildTag("integration", model.TestIntegration),
+	{pyLanguages, "pytest.mark.e2e", regexp.MustCompile(`pytest\.mark\.e2e\b`), model.TestE2E},
+	{pyLanguages, "pytest.mark.integration", regexp.MustCompile(`pytest\.mark\.integration\b`), model.TestIntegration},
+	{pyLanguages, "pytest.mark.benchmark", regexp.MustCompile(`pytest\.mark\.benchmark\b`), model.TestBenchmark},
+	{jvmLanguages, "@Tag", regexp.MustCompile(`@Tag\(\s*"(?i:e2e)"`), model.TestE2E},
+	{jvmLanguages, "@Tag", regexp.MustCompile(`@Tag\(\s*"(?i:integration)"`), model.TestIntegration},
+	{jvmLanguages, "@Category", regexp.MustCompile(`@Category\([^)]*Integration`), model.TestIntegration},
+	{jvmLanguages, "@SpringBootTest", regexp.MustCompile(`@SpringBootTest\b`), model.TestIntegration},
+	{jvmLanguages, "@Testcontainers", regexp.MustCompile(`@Testcontainers\b`), model.TestIntegration},
+
+	// Regular tests
+	{goLanguages, "func Test", regexp.MustCompile(`^func Test\w*\(`), model.TestUnit},
+	{rustLanguages, "#[test]", regexp.MustCompile(`#\[test\]`), model.TestUnit},
+	{jsLanguages, "(", regexp.MustCompile(`^\s*(describe|it|test)\(`), model.TestUnit},
+
+	// Benchmarks and fuzz targets
+	{goLanguages, "func Benchmark", regexp.MustCompile(`^func Benchmark\w*\(`), model.TestBenchmark},
+	{goLanguages, "func Fuzz", regexp.MustCompile(`^func Fuzz\w*\(`), model.TestFuzz},
+	{rustLanguages, "#[bench]", regexp.MustCompile(`#\[bench\]`), model.TestBenchmark},
+	{rustLanguages, "criterion_group!", regexp.MustCompile(`criterion_group!`), model.TestBenchmark},
+	{rustLanguages, "fuzz_target!", regexp.MustCompile(`fuzz_target!`), model.TestFuzz},
+	{jvmLanguages, "@Benchmark", regexp.MustCompile(`@Benchmark\b`), model.TestBenchmark},
+	{jvmLanguages, "@FuzzTest", regexp.MustCompile(`@FuzzTest\b`), model.TestFuzz},
+	{jsLanguages, "bench(", regexp.MustCompile(`^\s*bench\(`), model.TestBenchmark},
+}
+
+// testKindMarkersByLanguage holds each language's markers, in order
+var testKindMarkersByLanguage = func() map[string][]testKindMarker {
+	byLanguage := make(map[string][]testKindMarker)
+	for _, m := range testKindMarkers {
+		for _, lang := range m.languages {
+			byLanguage[lang] = append(byLanguage[lang], m)
+		}
+	}
+	return byLanguage
+}()
+
+// maxMarkerLine caps the marker line kept for explanations
+const maxMarkerLine = 120
+
+// markerScan finds the first-ranked test kind marker among a file's lines
+type markerScan struct {
+	markers []testKindMarker
+	found   int // rank of the marker found; len(markers) = none yet
+	marker  model.TestMarker
+}
+
+func newMarkerScan(lang string) markerScan {
+	markers := testKindMarkersByLanguage[lang]
+	return markerScan{markers: markers, found: len(markers)}
+}
+
+// scan checks a line against the markers ranked above the one found so far
+func (s *markerScan) scan(line []byte) {
+	for i, m := range s.markers[:s.found] {
+		if bytes.Contains(line, []byte(m.literal)) && m.pattern.Match(line) {
+			line = bytes.TrimSpace(line)
+			s.found = i
+			s.marker = model.TestMarker{Kind: m.kind, Line: string(line[:min(len(line), maxMarkerLine)])}
+			return
+		}
+	}
+}
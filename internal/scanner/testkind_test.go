@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modern-tooling/aloc/internal/model"
+)
+
+func TestCountContent_TestMarker(t *testing.T) {
+	tests := []struct {
+		lang    string
+		content string
+		want    model.TestKind
+	}{
+		{"Go", "//go:build integration\n\npackage store\n", model.TestIntegration},
+		{"Go", "//go:build linux && (e2e || smoke)\n\npackage store\n", model.TestE2E},
+		{"Go", "//go:build !integration\n\npackage store\n\nfunc TestOpen(t *testing.T) {\n}\n", model.TestUnit},
+		{"Go", "package store\n\nfunc BenchmarkQuery(b *testing.B) {\n}\n", model.TestBenchmark},
+		// a regular test outranks a benchmark further up
+		{"Go", "package store\n\nfunc BenchmarkQuery(b *testing.B) {\n}\n\nfunc TestQuery(t *testing.T) {\n}\n", model.TestUnit},
+		{"Go", "package store\n\nfunc FuzzParse(f *testing.F) {\n}\n", model.TestFuzz},
+		{"Python", "import pytest\n\npytestmark = pytest.mark.e2e\n", model.TestE2E},
+		{"Java", "@Tag(\"integration\")\nclass OrderRepositoryTest {\n}\n", model.TestIntegration},
+		{"Kotlin", "@SpringBootTest\nclass OrderServiceTest {\n}\n", model.TestIntegration},
+		{"Rust", "#[bench]\nfn parse(b: &mut Bencher) {\n}\n", model.TestBenchmark},
+		{"TypeScript", "describe('cart', () => {\n});\n", model.TestUnit},
+		{"TSX", "import { render } from '@testing-library/react';\n\nbench('render', () => {\n});\n", model.TestBenchmark},
+		// markers of another language don't count
+		{"Python", "func BenchmarkQuery(b *testing.B) {\n", ""},
+		{"Markdown", "```go\nfunc BenchmarkQuery(b *testing.B) {\n```\n", ""},
+	}
+
+	for _, tt := range tests {
+		if got := countContentAs([]byte(tt.content), tt.lang).marker.Kind; got != tt.want {
+			t.Errorf("marker of %s %q = %q, want %q", tt.lang, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestScanner_TestMarkerPastHeader(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	// the first benchmark is far past the bytes header probing reads
+	content := "package store\n\n" + strings.Repeat("var x = 1\n", 2*headerSize/10) + "func BenchmarkQuery(b *testing.B) {\n}\n"
+	writeFiles(t, root, map[string]string{"store/query_test.go": content})
+	setMtime(t, filepath.Join(root, "store/query_test.go"), time.Now().Add(-time.Hour))
+
+	cachePath := filepath.Join(t.TempDir(), "cache.gob")
+	cache := OpenCache(cachePath, "test")
+	// header probing is off
+	first := scanWith(t, root, Options{Cache: cache})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	second := scanWith(t, root, Options{Cache: OpenCache(cachePath, "test")})
+
+	want := model.TestMarker{Kind: model.TestBenchmark, Line: "func BenchmarkQuery(b *testing.B) {"}
+	for i, files := range []map[string]*model.RawFile{first, second} {
+		if got := files["store/query_test.go"].TestMarker; got != want {
+			t.Errorf("scan %d: marker = %+v, want %+v", i, got, want)
+		}
+	}
+}
@@ -6,41 +6,49 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 )
 
-// knownSourceExtensions contains extensions that are recognized as source code
-// in quick mode. Files without these extensions are skipped to avoid processing
-// binaries and generated files.
-var knownSourceExtensions = map[string]bool{
-	".go": true, ".py": true, ".js": true, ".ts": true, ".tsx": true, ".jsx": true,
-	".java": true, ".kt": true, ".scala": true, ".rs": true, ".c": true, ".cpp": true,
-	".h": true, ".hpp": true, ".cs": true, ".rb": true, ".php": true, ".swift": true,
-	".m": true, ".mm": true, ".sql": true, ".sh": true, ".bash": true, ".zsh": true,
-	".yaml": true, ".yml": true, ".json": true, ".xml": true, ".html": true, ".css": true,
-	".scss": true, ".sass": true, ".less": true, ".vue": true, ".svelte": true,
-	".md": true, ".mdx": true, ".rst": true, ".txt": true,
-	".tf": true, ".hcl": true, ".proto": true, ".graphql": true,
-	".lua": true, ".r": true, ".R": true, ".pl": true, ".pm": true,
-	".ex": true, ".exs": true, ".erl": true, ".hs": true, ".clj": true,
-	".toml": true, ".ini": true, ".cfg": true, ".conf": true,
+// extraSourceExtensions are scanned in quick mode although no language in
+// languages.json claims them
+var extraSourceExtensions = map[string]bool{
+	".conf": true,
 }
 
+// isKnownSourceExtension reports whether an extension (with its leading dot)
+// belongs to a language in the language table, including user-defined ones.
+// Quick mode only scans these, skipping binaries and unknown formats.
 func isKnownSourceExtension(ext string) bool {
-	return knownSourceExtensions[ext]
+	ext = strings.ToLower(ext)
+	if _, ok := extToLang[strings.TrimPrefix(ext, ".")]; ok {
+		return true
+	}
+	return extraSourceExtensions[ext]
 }
 
 type Walker struct {
-	root       string
-	numWorkers int
-	exclude    []string
-	deepMode   bool
-	gitignore  *GitIgnore
+	root              string
+	numWorkers        int
+	exclude           []string
+	deepMode          bool
+	includeExtensions map[string]bool
+	skipExtensions    map[string]bool
+	gitignore         *GitIgnore
+	attributes        *GitAttributes
+	submodules        SubmoduleMode
+	gitmodules        map[string]bool // submodule paths from .gitmodules
+	manifests         *manifestSet    // build manifests collected during the walk
+	nested            sync.Map        // relPath -> struct{}, nested repos seen during the walk
 }
 
 type WalkOptions struct {
-	NumWorkers int
-	Exclude    []string
-	DeepMode   bool
+	NumWorkers        int
+	Exclude           []string
+	DeepMode          bool
+	IncludeExtensions []string // scanned in quick mode in addition to known languages
+	SkipExtensions    []string // never scanned, in quick or deep mode
+	Submodules        SubmoduleMode
+	Manifests         []string // file names collected whether or not they are counted
 }
 
 func NewWalker(root string, opts WalkOptions) (*Walker, error) {
@@ -60,13 +68,24 @@ func NewWalker(root string, opts WalkOptions) (*Walker, error) {
 	}
 
 	gitignore, _ := LoadGitIgnore(absRoot) // ignore errors, gitignore is optional
+	attributes, _ := LoadGitAttributes(absRoot)
+
+	if opts.Submodules == "" {
+		opts.Submodules = SubmodulesVendor
+	}
 
 	return &Walker{
-		root:       absRoot,
-		numWorkers: opts.NumWorkers,
-		exclude:    opts.Exclude,
-		deepMode:   opts.DeepMode,
-		gitignore:  gitignore,
+		root:              absRoot,
+		numWorkers:        opts.NumWorkers,
+		exclude:           opts.Exclude,
+		deepMode:          opts.DeepMode,
+		includeExtensions: extensionSet(opts.IncludeExtensions),
+		skipExtensions:    extensionSet(opts.SkipExtensions),
+		gitignore:         gitignore,
+		attributes:        attributes,
+		submodules:        opts.Submodules,
+		gitmodules:        readGitmodules(absRoot),
+		manifests:         newManifestSet(opts.Manifests),
 	}, nil
 }
 
@@ -101,68 +120,162 @@ func (w *Walker) Walk(ctx context.Context) (<-chan string, <-chan error) {
 
 			// skip excluded patterns
 			relPath, _ := filepath.Rel(w.root, path)
-			for _, pattern := range w.exclude {
-				if matched, _ := filepath.Match(pattern, relPath); matched {
-					if d.IsDir() {
-						return filepath.SkipDir
-					}
-					return nil
-				}
-				if strings.Contains(relPath, strings.TrimSuffix(strings.TrimPrefix(pattern, "**/"), "/**")) {
-					if d.IsDir() {
-						return filepath.SkipDir
-					}
-					return nil
+			if w.isExcluded(relPath) {
+				if d.IsDir() {
+					return filepath.SkipDir
 				}
+				return nil
 			}
 
 			// skip directories
 			if d.IsDir() {
-				name := d.Name()
-				// skip common cache, build, and dependency directories
-				switch name {
-				case ".git", "vendor", "node_modules",
-					// package manager caches
-					".pnpm-store", ".yarn", ".npm",
-					// build/cache directories
-					".terraform", ".terragrunt-cache",
-					".nx", ".turbo", ".next", ".nuxt", ".cache",
-					".venv", "venv", "__pycache__", ".pytest_cache",
-					".gradle", ".m2",
-					// IDE directories
-					".idea", ".vscode",
-					// OS directories
-					".DS_Store",
-					// git hooks
-					".husky",
-					// other caches
-					"dist", "build", "target", "out",
-					".angular", ".svelte-kit",
-					// generated/temp directories
-					"generated", "tmp":
+				if isSkippedDir(d.Name()) {
 					return filepath.SkipDir
 				}
+				// submodules and nested checkouts are tagged (or pruned) before
+				// any of their files are sent
+				if w.isNestedRepo(path, relPath) {
+					if w.submodules == SubmodulesSkip {
+						return filepath.SkipDir
+					}
+					w.nested.Store(relPath, struct{}{})
+				}
+				// stack this directory's ignore and attributes files before
+				// visiting its children
+				if w.gitignore != nil {
+					if err := w.gitignore.LoadDir(path); err != nil {
+						errs <- err
+					}
+				}
+				if w.attributes != nil {
+					if err := w.attributes.LoadDir(path); err != nil {
+						errs <- err
+					}
+				}
 				return nil
 			}
 
-			// in quick mode, only process files with known source extensions
-			// (skip extensionless files which are usually binaries or generated)
-			if !w.deepMode {
-				ext := strings.ToLower(filepath.Ext(path))
-				if ext == "" || !isKnownSourceExtension(ext) {
-					return nil
-				}
+			if w.collectsManifest(relPath) {
+				w.manifests.readFile(path, relPath)
+			}
+
+			// a submodule's .git is a gitlink file, not source
+			if d.Name() == ".git" || !w.acceptsFile(path) {
+				return nil
 			}
 
 			// binary check moved to CountLOC for single file open
-			paths <- path
+			select {
+			case paths <- path:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 			return nil
 		})
 
-		if err != nil && err != context.Canceled {
+		if err != nil && ctx.Err() == nil {
 			errs <- err
 		}
 	}()
 
 	return paths, errs
 }
+
+// isExcluded reports whether a root-relative path matches an exclude pattern
+func (w *Walker) isExcluded(relPath string) bool {
+	for _, pattern := range w.exclude {
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+		if strings.Contains(relPath, strings.TrimSuffix(strings.TrimPrefix(pattern, "**/"), "/**")) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectsManifest reports whether a root-relative file is a manifest to
+// collect; those of vendored nested repositories are not
+func (w *Walker) collectsManifest(relPath string) bool {
+	if !w.manifests.wants(filepath.Base(relPath)) {
+		return false
+	}
+	return w.submodules == SubmodulesRecurse || w.submoduleOf(relPath) == ""
+}
+
+// isSkippedDir reports whether a directory name is a common cache, build or
+// dependency directory that is never scanned
+func isSkippedDir(name string) bool {
+	switch name {
+	case ".git", "vendor", "node_modules",
+		// package manager caches
+		".pnpm-store", ".yarn", ".npm",
+		// build/cache directories
+		".terraform", ".terragrunt-cache",
+		".nx", ".turbo", ".next", ".nuxt", ".cache",
+		".venv", "venv", "__pycache__", ".pytest_cache",
+		".gradle", ".m2",
+		// IDE directories
+		".idea", ".vscode",
+		// OS directories
+		".DS_Store",
+		// git hooks
+		".husky",
+		// other caches
+		"dist", "build", "target", "out",
+		".angular", ".svelte-kit",
+		// generated/temp directories
+		"generated", "tmp":
+		return true
+	}
+	return false
+}
+
+// acceptsFile applies the extension filters: skipped extensions are dropped,
+// and in quick mode only files of a known language (by extension or special
+// filename such as Dockerfile) or an included extension are processed
+func (w *Walker) acceptsFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if w.skipExtensions[ext] {
+		return false
+	}
+	if w.deepMode {
+		return true
+	}
+	if _, ok := filenameToLang[strings.ToLower(filepath.Base(path))]; ok {
+		return true
+	}
+	return ext != "" && (isKnownSourceExtension(ext) || w.includeExtensions[ext])
+}
+
+// extensionSet normalizes configured extensions ("kts", ".KTS") to ".kts"
+func extensionSet(exts []string) map[string]bool {
+	set := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		if ext = strings.ToLower(strings.TrimSpace(ext)); ext != "" {
+			set["."+strings.TrimPrefix(ext, ".")] = true
+		}
+	}
+	return set
+}
+
+// includesTreePath applies the walker's filters to a root-relative file path
+// from a git tree, where there is no directory walk to prune
+func (w *Walker) includesTreePath(relPath string) bool {
+	return w.visitsTreePath(relPath) && w.acceptsFile(relPath)
+}
+
+// visitsTreePath reports whether a walk would visit a root-relative file
+// path from a git tree: it is not excluded, nor below a skipped directory
+func (w *Walker) visitsTreePath(relPath string) bool {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(relPath)), "/")
+	for i, dir := range dirs {
+		if dir == "." {
+			continue
+		}
+		if isSkippedDir(dir) || w.isExcluded(filepath.FromSlash(strings.Join(dirs[:i+1], "/"))) {
+			return false
+		}
+	}
+	return !w.isExcluded(relPath)
+}
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func walkWith(t *testing.T, root string, opts WalkOptions) []string {
+	t.Helper()
+	w, err := NewWalker(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, errs := w.Walk(context.Background())
+	var got []string
+	for p := range paths {
+		rel, _ := filepath.Rel(w.root, p)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	for err := range errs {
+		t.Errorf("walk error: %v", err)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestWalker_QuickModeExtensions(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"build.gradle.kts": "plugins {}\n",
+		"lib/main.dart":    "void main() {}\n",
+		"src/util.zig":     "const std = @import(\"std\");\n",
+		"Dockerfile":       "FROM scratch\n",
+		"data.bin":         "\x00\x01",
+		"notes":            "extensionless\n",
+		"app.custom":       "x\n",
+		"schema.json":      "{}\n",
+	})
+
+	got := walkWith(t, root, WalkOptions{})
+	assertPaths(t, got, []string{"Dockerfile", "build.gradle.kts", "lib/main.dart", "schema.json", "src/util.zig"})
+
+	got = walkWith(t, root, WalkOptions{
+		IncludeExtensions: []string{"custom"},
+		SkipExtensions:    []string{".JSON"},
+	})
+	assertPaths(t, got, []string{"Dockerfile", "app.custom", "build.gradle.kts", "lib/main.dart", "src/util.zig"})
+}
+
+func TestWalker_SkipExtensionsInDeepMode(t *testing.T) {
+	isolateGitConfig(t)
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"main.go":  "package main\n",
+		"logo.svg": "<svg/>\n",
+		"notes":    "extensionless\n",
+	})
+
+	got := walkWith(t, root, WalkOptions{DeepMode: true, SkipExtensions: []string{"svg"}})
+	assertPaths(t, got, []string{"main.go", "notes"})
+}
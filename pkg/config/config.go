@@ -9,14 +9,65 @@ import (
 )
 
 type Config struct {
-	Overrides map[model.Role][]string `yaml:"overrides"`
-	Exclude   []string                `yaml:"exclude"`
-	Options   Options                 `yaml:"options"`
+	Overrides         map[model.Role][]string `yaml:"overrides"`
+	Exclude           []string                `yaml:"exclude"`
+	IncludeExtensions []string                `yaml:"include_extensions"` // also scanned in quick mode
+	SkipExtensions    []string                `yaml:"skip_extensions"`    // never scanned
+	Options           Options                 `yaml:"options"`
+	Languages         map[string]Language     `yaml:"languages"`
+	Rules             Rules                   `yaml:"rules"`
+	Roles             map[model.Role]Role     `yaml:"roles"`
 }
 
 type Options struct {
-	HeaderProbe  bool `yaml:"header_probe"`
-	Neighborhood bool `yaml:"neighborhood"`
+	HeaderProbe  bool   `yaml:"header_probe"`
+	Neighborhood bool   `yaml:"neighborhood"`
+	Submodules   string `yaml:"submodules"` // vendor (default), skip or recurse
+}
+
+// Language defines a new language, or extends the built-in language of the
+// same name. Listed extensions and filenames are mapped to it, taking them
+// over from any language that claimed them before; comment markers, quotes
+// and category replace the built-in ones when set.
+type Language struct {
+	Extensions        []string   `yaml:"extensions"`
+	Filenames         []string   `yaml:"filenames"`
+	LineComment       []string   `yaml:"line_comment"`
+	MultiLineComments [][]string `yaml:"multi_line_comments"`
+	Nested            bool       `yaml:"nested"`
+	Quotes            [][]string `yaml:"quotes"`
+	Category          string     `yaml:"category"` // primary, web, infra, data, docs or other
+}
+
+// Role declares a role beyond the built-in ones. Files are assigned to it
+// by overrides or rules.
+type Role struct {
+	DisplayName string `yaml:"display_name"`
+	Color       string `yaml:"color"` // semantic color token, e.g. fragility or operational
+	Order       int    `yaml:"order"` // position among roles (built-ins are 10, 20, ... 100); after them when 0
+	Core        bool   `yaml:"core"`  // count its code as core in ratios and effort
+}
+
+// Rules adds, reweights or disables the weighted classification rules,
+// per table. Unlike overrides they are hints: they add to a file's score
+// alongside the built-in rules and neighborhood inference.
+type Rules struct {
+	Path      []Rule `yaml:"path"`
+	Filename  []Rule `yaml:"filename"`
+	Extension []Rule `yaml:"extension"`
+	Header    []Rule `yaml:"header"`
+}
+
+// Rule is one classification rule. A rule with the match, type and role of
+// a built-in one changes its weight; disable removes the built-in rules with
+// that match.
+type Rule struct {
+	Match   string         `yaml:"match"` // path fragment, filename pattern, extension or header text
+	Type    string         `yaml:"type"`  // filename: suffix, prefix or contains (default); header: substring (default) or regex
+	Role    model.Role     `yaml:"role"`
+	SubRole model.TestKind `yaml:"sub_role"`
+	Weight  float32        `yaml:"weight"`
+	Disable bool           `yaml:"disable"`
 }
 
 func DefaultConfig() *Config {